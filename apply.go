@@ -0,0 +1,97 @@
+package kino
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyTo projects the Go value pointed to by ptr in place: every struct field
+// (matched by its JSON name) or map entry that MarshalWithMask would drop is
+// reset to its zero value, or deleted in the case of map entries. The walk
+// recurses into nested structs, pointers, interfaces, slices, arrays and
+// string-keyed maps using the same Positive/Negative/override semantics as the
+// JSON projector; slice and array elements inherit the mask of their parent.
+// Fields that are invisible to JSON (unexported or tagged `json:"-"`) are left
+// untouched.
+//
+// ptr must be a non-nil pointer so that the pointee is addressable. A nil mask
+// leaves the value untouched.
+func ApplyTo(ptr any, m *Mask) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("apply mask: value of type %T is not addressable, need non-nil pointer", ptr)
+	}
+	applyValue(rv.Elem(), m)
+	return nil
+}
+
+// applyValue zeroes the parts of v excluded by mask. v must be settable for
+// struct fields to be cleared.
+func applyValue(v reflect.Value, mask *Mask) {
+	if mask == nil {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			applyValue(v.Elem(), mask)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		elem := v.Elem()
+		switch elem.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice:
+			// Reference kinds can be modified through a copy of the header.
+			applyValue(elem, mask)
+		default:
+			if !v.CanSet() {
+				return
+			}
+			cp := reflect.New(elem.Type()).Elem()
+			cp.Set(elem)
+			applyValue(cp, mask)
+			v.Set(cp)
+		}
+	case reflect.Struct:
+		for _, f := range jsonFields(v.Type()) {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				continue // field promoted through a nil embedded pointer
+			}
+			keep, sub := mask.Resolve(f.name)
+			if !keep {
+				if fv.CanSet() {
+					fv.SetZero()
+				}
+				continue
+			}
+			applyValue(fv, sub)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			applyValue(v.Index(i), mask)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			keep, sub := mask.Resolve(k.String())
+			if !keep {
+				v.SetMapIndex(k, reflect.Value{})
+				continue
+			}
+			if sub == nil {
+				continue
+			}
+			// Map elements are not addressable: project a copy and store it
+			// back.
+			cp := reflect.New(v.Type().Elem()).Elem()
+			cp.Set(v.MapIndex(k))
+			applyValue(cp, sub)
+			v.SetMapIndex(k, cp)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+type applyMeta struct {
+	Plan     string `json:"plan"`
+	Internal string `json:"internal"`
+}
+
+type applyBase struct {
+	ID int `json:"id"`
+}
+
+type applyUser struct {
+	applyBase
+	Name   string               `json:"name"`
+	SSN    string               `json:"ssn,omitempty"`
+	Meta   *applyMeta           `json:"meta"`
+	Tags   []applyMeta          `json:"tags"`
+	Extra  map[string]applyMeta `json:"extra"`
+	Any    any                  `json:"any"`
+	Hidden string               `json:"-"`
+	NoTag  string
+}
+
+func buildApplyUser() applyUser {
+	return applyUser{
+		applyBase: applyBase{ID: 1},
+		Name:      "Ada",
+		SSN:       "123",
+		Meta:      &applyMeta{Plan: "pro", Internal: "secret"},
+		Tags:      []applyMeta{{Plan: "a", Internal: "x"}, {Plan: "b", Internal: "y"}},
+		Extra:     map[string]applyMeta{"k": {Plan: "p", Internal: "i"}, "drop": {}},
+		Any:       map[string]any{"keep": 1, "drop": 2},
+		Hidden:    "h",
+		NoTag:     "n",
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+	t.Run("non-pointer error", func(t *testing.T) {
+		m, err := kino.ParseMask("id")
+		require.NoError(t, err)
+		require.Error(t, kino.ApplyTo(buildApplyUser(), m))
+	})
+
+	t.Run("nil pointer error", func(t *testing.T) {
+		var u *applyUser
+		require.Error(t, kino.ApplyTo(u, nil))
+	})
+
+	t.Run("nil mask untouched", func(t *testing.T) {
+		u := buildApplyUser()
+		require.NoError(t, kino.ApplyTo(&u, nil))
+		require.Equal(t, buildApplyUser(), u)
+	})
+
+	t.Run("id,meta:(plan),tags:(-internal) positive zeroed", func(t *testing.T) {
+		m, err := kino.ParseMask("id,meta:(plan),tags:(-internal)")
+		require.NoError(t, err)
+		u := buildApplyUser()
+		require.NoError(t, kino.ApplyTo(&u, m))
+		require.Equal(t, applyUser{
+			applyBase: applyBase{ID: 1},
+			Meta:      &applyMeta{Plan: "pro"},
+			Tags:      []applyMeta{{Plan: "a"}, {Plan: "b"}},
+			Hidden:    "h", // not visible to JSON, so never masked
+		}, u)
+	})
+
+	t.Run("-ssn,-meta:(plan) negative zeroed", func(t *testing.T) {
+		m, err := kino.ParseMask("-ssn,-meta:(plan),-extra:(k:(plan)),-any:(keep)")
+		require.NoError(t, err)
+		u := buildApplyUser()
+		require.NoError(t, kino.ApplyTo(&u, m))
+		want := buildApplyUser()
+		want.SSN = ""
+		want.Meta = &applyMeta{Plan: "pro"}
+		want.Extra = map[string]applyMeta{"k": {Plan: "p"}}
+		want.Any = map[string]any{"keep": 1}
+		require.Equal(t, want, u)
+	})
+
+	t.Run("matches MarshalWithMask", func(t *testing.T) {
+		for _, expr := range []string{"id,name", "-ssn,-tags", "meta:(-internal),extra:(k)", "-meta:(plan),NoTag"} {
+			m, err := kino.ParseMask(expr)
+			require.NoError(t, err)
+			u := buildApplyUser()
+			want, err := json.Marshal(u, json.WithMarshalers(kino.MarshalWithMask(m)))
+			require.NoError(t, err)
+			require.NoError(t, kino.ApplyTo(&u, m))
+			got, err := json.Marshal(u, json.WithMarshalers(kino.MarshalWithMask(m)))
+			require.NoError(t, err)
+			require.JSONEq(t, string(want), string(got), expr)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package kino
+
+// Observer is called by MarshalWithMask for every member it examines, with the
+// member's dotted path (array indices omitted) and whether it was emitted.
+// Emitted members are reported at every depth, including those inside subtrees
+// copied verbatim; a suppressed (removed or redacted) member is reported once,
+// without the members nested below it.
+type Observer func(path string, emitted bool)
+
+// Stats holds summary counters of a MarshalWithMask projection.
+type Stats struct {
+	// KeysKept counts the emitted members at every depth.
+	KeysKept int
+	// KeysSkipped counts the members removed or redacted by the mask, not
+	// including the members nested below them.
+	KeysSkipped int
+	// BytesSkipped is the size of the source JSON values of skipped members,
+	// encoded compactly regardless of the encoder's formatting options.
+	BytesSkipped int64
+}
+
+// WithObserver makes MarshalWithMask call fn for every emitted and every
+// suppressed member, e.g. to audit which sensitive fields were sent.
+func WithObserver(fn Observer) Option {
+	return func(o *options) {
+		o.observer = fn
+	}
+}
+
+// WithStats makes MarshalWithMask store the counters of each projection in s.
+// Like a Report, s is overwritten by every marshal call that uses it and must
+// not be shared between concurrent calls.
+func WithStats(s *Stats) Option {
+	return func(o *options) {
+		o.stats = s
+	}
+}
@@ -0,0 +1,84 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+func TestMarshalWithMask_Audit(t *testing.T) {
+	const doc = `{"a":"va","b":"vb","c":{"d":1,"e":[1,2]},"l":[{"x":1,"y":2},{"x":3}]}`
+	type event struct {
+		path    string
+		emitted bool
+	}
+
+	t.Run("observer and stats", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c,l:(x)")
+		require.NoError(t, err)
+		var events []event
+		var stats kino.Stats
+		out, err := json.Marshal(jsontext.Value(doc), json.WithMarshalers(kino.MarshalWithMask(m,
+			kino.WithObserver(func(path string, emitted bool) { events = append(events, event{path, emitted}) }),
+			kino.WithStats(&stats),
+		)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va","c":{"d":1,"e":[1,2]},"l":[{"x":1},{"x":3}]}`, string(out))
+		require.Equal(t, []event{
+			{"a", true},
+			{"b", false},
+			{"c", true},
+			{"c.d", true},
+			{"c.e", true},
+			{"l", true},
+			{"l.x", true},
+			{"l.y", false},
+			{"l.x", true},
+		}, events)
+		require.Equal(t, kino.Stats{KeysKept: 7, KeysSkipped: 2, BytesSkipped: int64(len(`"vb"`) + len(`2`))}, stats)
+	})
+
+	t.Run("stats without observer", func(t *testing.T) {
+		m, err := kino.ParseMask("-c,-l")
+		require.NoError(t, err)
+		var stats kino.Stats
+		_, err = json.Marshal(jsontext.Value(doc), json.WithMarshalers(kino.MarshalWithMask(m, kino.WithStats(&stats))))
+		require.NoError(t, err)
+		require.Equal(t, kino.Stats{
+			KeysKept:     2,
+			KeysSkipped:  2,
+			BytesSkipped: int64(len(`{"d":1,"e":[1,2]}`) + len(`[{"x":1,"y":2},{"x":3}]`)),
+		}, stats)
+	})
+
+	t.Run("stats ignore indentation", func(t *testing.T) {
+		m, err := kino.ParseMask("-c,-l")
+		require.NoError(t, err)
+		var stats kino.Stats
+		indented := jsontext.Value(`{ "a": "va", "b": "vb", "c": { "d": 1, "e": [ 1, 2 ] }, "l": [ { "x": 1, "y": 2 }, { "x": 3 } ] }`)
+		out, err := json.Marshal(indented, json.WithMarshalers(kino.MarshalWithMask(m, kino.WithStats(&stats))), jsontext.WithIndent("  "))
+		require.NoError(t, err)
+		require.Equal(t, "{\n  \"a\": \"va\",\n  \"b\": \"vb\"\n}", string(out))
+		require.Equal(t, int64(len(`{"d":1,"e":[1,2]}`)+len(`[{"x":1,"y":2},{"x":3}]`)), stats.BytesSkipped)
+	})
+
+	t.Run("redacted members reported suppressed", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		suppressed := map[string]bool{}
+		_, err = json.Marshal(jsontext.Value(doc), json.WithMarshalers(kino.MarshalWithMask(m,
+			kino.WithRedaction(kino.RedactNull()),
+			kino.WithObserver(func(path string, emitted bool) {
+				if !emitted {
+					suppressed[path] = true
+				}
+			}),
+		)))
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"b": true, "c": true, "l": true}, suppressed)
+	})
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultJSONText is the jsontext package MarshalMasked methods import by
+// default.
+const defaultJSONText = "github.com/go-json-experiment/json/jsontext"
+
+// reservedMethods are promoted from the embedded kino.FieldPath and cannot be
+// used as accessor names.
+var reservedMethods = map[string]bool{"Path": true, "Child": true, "String": true}
+
+// sourcePackage holds the struct declarations of the package being generated.
+type sourcePackage struct {
+	name    string
+	structs map[string]*ast.StructType // named struct types by name
+	order   []string                   // struct names in declaration order
+	methods map[string]map[string]bool // method names by receiver type name
+}
+
+// loadPackage parses the non-test Go files of dir, skipping previously
+// generated output.
+func loadPackage(dir string) (*sourcePackage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	pkg := &sourcePackage{structs: make(map[string]*ast.StructType), methods: make(map[string]map[string]bool)}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") || strings.HasSuffix(path, "_kino.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if pkg.name == "" {
+			pkg.name = f.Name.Name
+		} else if pkg.name != f.Name.Name {
+			return nil, fmt.Errorf("multiple packages in %s: %s, %s", dir, pkg.name, f.Name.Name)
+		}
+		pkg.addFile(f)
+	}
+	if pkg.name == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return pkg, nil
+}
+
+// addFile records the struct type declarations and the method names of f.
+func (p *sourcePackage) addFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv != nil && len(fd.Recv.List) == 1 {
+			recv := embeddedName(fd.Recv.List[0].Type)
+			if p.methods[recv] == nil {
+				p.methods[recv] = make(map[string]bool)
+			}
+			p.methods[recv][fd.Name.Name] = true
+			continue
+		}
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if st, ok := ts.Type.(*ast.StructType); ok && ts.TypeParams == nil {
+				p.structs[ts.Name.Name] = st
+				p.order = append(p.order, ts.Name.Name)
+			}
+		}
+	}
+}
+
+// selfMarshalers are the methods through which a type marshals itself instead
+// of being encoded field by field.
+var selfMarshalers = []string{"MarshalJSONTo", "MarshalJSON", "MarshalText"}
+
+// selfMarshaler describes how the named type marshals itself: through one of
+// selfMarshalers, declared on it or promoted from an embedded field. It is
+// empty for types encoded field by field. Embedded types of other packages
+// may promote such methods unseen, so they count as marshaling themselves.
+func (p *sourcePackage) selfMarshaler(name string, seen map[string]bool) string {
+	if seen[name] {
+		return ""
+	}
+	seen[name] = true
+	for _, m := range selfMarshalers {
+		if p.methods[name][m] {
+			return m
+		}
+	}
+	st, ok := p.structs[name]
+	if !ok {
+		return ""
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) > 0 {
+			continue
+		}
+		t := f.Type
+		if s, ok := t.(*ast.StarExpr); ok {
+			t = s.X
+		}
+		if _, external := t.(*ast.SelectorExpr); external {
+			return "embedded " + types.ExprString(f.Type)
+		}
+		if m := p.selfMarshaler(embeddedName(t), seen); m != "" {
+			return m + " of embedded " + types.ExprString(f.Type)
+		}
+	}
+	return ""
+}
+
+// field is a JSON-visible struct field.
+type field struct {
+	goName   string
+	jsonName string
+	typ      ast.Expr
+	opts     string   // json tag options
+	sel      []string // Go selector path from the struct being generated
+	embedPtr bool     // sel passes through an embedded pointer
+	promoted bool     // flattened from an embedded or inline struct
+}
+
+// generator accumulates the accessor types to emit.
+type generator struct {
+	pkg     *sourcePackage
+	marshal bool // also emit MarshalMasked methods
+	buf     bytes.Buffer
+	emitted map[string]bool // accessor type names already generated
+	queue   []accessor
+}
+
+// accessor is a pending accessor type for a struct.
+type accessor struct {
+	typeName string // accessor type name, e.g. UserFields
+	goType   string // named struct type, empty for anonymous structs
+	doc      string // what the accessor selects
+	st       *ast.StructType
+}
+
+// generate returns the unformatted source of the accessors for the named
+// types, or for every exported struct when types is empty. With marshal set,
+// the named structs reached also get MarshalMasked methods, which import the
+// jsontext package at jsontextPath.
+func generate(pkg *sourcePackage, types []string, marshal bool, jsontextPath string) ([]byte, error) {
+	if len(types) == 0 {
+		for _, name := range pkg.order {
+			if ast.IsExported(name) {
+				types = append(types, name)
+			}
+		}
+	}
+	g := &generator{pkg: pkg, marshal: marshal, emitted: make(map[string]bool)}
+	fmt.Fprintf(&g.buf, "// Code generated by kinogen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "package %s\n\n", pkg.name)
+	if marshal {
+		fmt.Fprintf(&g.buf, "import (\n\t%q\n\n\t\"github.com/calumari/kino\"\n)\n", jsontextPath)
+	} else {
+		fmt.Fprintf(&g.buf, "import \"github.com/calumari/kino\"\n")
+	}
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		st, ok := pkg.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("struct type %s not found in package %s", name, pkg.name)
+		}
+		fmt.Fprintf(&g.buf, "\n// %sMask is the root of the typed field paths of %s.\n", name, name)
+		fmt.Fprintf(&g.buf, "var %sMask = %sFields{}\n", name, name)
+		g.enqueue(name+"Fields", name, name, st)
+	}
+	for len(g.queue) > 0 {
+		a := g.queue[0]
+		g.queue = g.queue[1:]
+		if err := g.emit(a); err != nil {
+			return nil, err
+		}
+	}
+	return g.buf.Bytes(), nil
+}
+
+func (g *generator) enqueue(typeName, goType, doc string, st *ast.StructType) {
+	if g.emitted[typeName] {
+		return
+	}
+	g.emitted[typeName] = true
+	g.queue = append(g.queue, accessor{typeName: typeName, goType: goType, doc: doc, st: st})
+}
+
+// emit writes the accessor type a and its methods.
+func (g *generator) emit(a accessor) error {
+	fields, err := g.fields(a.st, make(map[*ast.StructType]bool))
+	if err != nil {
+		return fmt.Errorf("%s: %w", a.doc, err)
+	}
+	fmt.Fprintf(&g.buf, "\n// %s selects fields of %s.\n", a.typeName, a.doc)
+	fmt.Fprintf(&g.buf, "type %s struct{ kino.FieldPath }\n", a.typeName)
+	for _, f := range fields {
+		method := f.goName
+		if reservedMethods[method] {
+			method += "Field"
+		}
+		result, nested := g.resultType(a, f)
+		fmt.Fprintf(&g.buf, "\n// %s selects the %q field.\n", method, f.jsonName)
+		if nested == "" {
+			fmt.Fprintf(&g.buf, "func (f %s) %s() kino.FieldPath { return f.Child(%q) }\n", a.typeName, method, f.jsonName)
+			continue
+		}
+		fmt.Fprintf(&g.buf, "func (f %s) %s() %s { return %s{f.Child(%q)} }\n", a.typeName, method, result, result, f.jsonName)
+	}
+	if g.marshal && a.goType != "" {
+		g.emitMarshal(a.goType, fields)
+	}
+	return nil
+}
+
+// resultType returns the accessor type of field f of a, enqueuing it when
+// needed. nested is empty for fields without selectable children.
+func (g *generator) resultType(a accessor, f field) (result, nested string) {
+	switch t := elemType(f.typ).(type) {
+	case *ast.Ident:
+		st, ok := g.pkg.structs[t.Name]
+		if !ok {
+			return "", ""
+		}
+		g.enqueue(t.Name+"Fields", t.Name, t.Name, st)
+		return t.Name + "Fields", t.Name
+	case *ast.StructType:
+		name := strings.TrimSuffix(a.typeName, "Fields") + f.goName + "Fields"
+		g.enqueue(name, "", a.doc+"."+f.goName, t)
+		return name, name
+	}
+	return "", ""
+}
+
+// elemType strips pointers, slices and arrays from t: their elements share the
+// mask of the field itself.
+func elemType(t ast.Expr) ast.Expr {
+	for {
+		switch e := t.(type) {
+		case *ast.StarExpr:
+			t = e.X
+		case *ast.ArrayType:
+			if id, ok := e.Elt.(*ast.Ident); ok && (id.Name == "byte" || id.Name == "uint8") {
+				return e // encoded as a base64 string
+			}
+			t = e.Elt
+		case *ast.ParenExpr:
+			t = e.X
+		default:
+			return t
+		}
+	}
+}
+
+// fields returns the JSON-visible fields of st in declaration order, with
+// embedded and inline structs of the same package flattened. Direct fields
+// shadow promoted ones of the same name, and the first promoted field wins
+// among those.
+func (g *generator) fields(st *ast.StructType, visiting map[*ast.StructType]bool) ([]field, error) {
+	if visiting[st] {
+		return nil, nil
+	}
+	visiting[st] = true
+	defer delete(visiting, st)
+
+	var all []field
+	direct := make(map[string]bool) // names claimed by direct fields
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid struct tag %s: %w", f.Tag.Value, err)
+			}
+			tag = reflect.StructTag(s)
+		}
+		jsonTag := tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if len(f.Names) == 0 && name == "" || hasOption(opts, "inline") {
+			if inner := g.inlineStruct(f.Type); inner != nil {
+				fs, err := g.fields(inner, visiting)
+				if err != nil {
+					return nil, err
+				}
+				goName := embeddedName(f.Type)
+				if len(f.Names) > 0 {
+					goName = f.Names[0].Name
+				}
+				_, ptr := f.Type.(*ast.StarExpr)
+				for _, inner := range fs {
+					inner.promoted = true
+					inner.sel = append([]string{goName}, inner.sel...)
+					inner.embedPtr = inner.embedPtr || ptr
+					all = append(all, inner)
+				}
+				continue
+			}
+			// Types of other packages may be structs to flatten: their
+			// fields are unknown here.
+			if _, external := elemType(f.Type).(*ast.SelectorExpr); external || hasOption(opts, "inline") {
+				return nil, fmt.Errorf("cannot inline %s: struct not declared in package %s", types.ExprString(f.Type), g.pkg.name)
+			}
+		}
+		names := f.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(embeddedName(f.Type))}
+		}
+		for _, id := range names {
+			if !ast.IsExported(id.Name) {
+				continue
+			}
+			jsonName := name
+			if jsonName == "" {
+				jsonName = id.Name
+			}
+			direct[jsonName], direct[id.Name] = true, true
+			all = append(all, field{goName: id.Name, jsonName: jsonName, typ: f.Type, opts: opts, sel: []string{id.Name}})
+		}
+	}
+
+	res := all[:0]
+	seen := make(map[string]bool)
+	for _, f := range all {
+		if f.promoted && (direct[f.jsonName] || direct[f.goName] || seen[f.jsonName] || seen[f.goName]) {
+			continue
+		}
+		seen[f.jsonName], seen[f.goName] = true, true
+		f.promoted = false
+		res = append(res, f)
+	}
+	return res, nil
+}
+
+// inlineStruct returns the struct type behind an embedded or inline field
+// type, if it is declared in the package.
+func (g *generator) inlineStruct(t ast.Expr) *ast.StructType {
+	if s, ok := t.(*ast.StarExpr); ok {
+		t = s.X
+	}
+	switch t := t.(type) {
+	case *ast.Ident:
+		return g.pkg.structs[t.Name]
+	case *ast.StructType:
+		return t
+	}
+	return nil
+}
+
+// embeddedName returns the field name of an embedded field of type t.
+func embeddedName(t ast.Expr) string {
+	switch e := t.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	}
+	return ""
+}
+
+// hasOption reports whether the comma separated tag options contain opt.
+func hasOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
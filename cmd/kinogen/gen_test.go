@@ -0,0 +1,138 @@
+package main
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/cmd/kinogen/internal/example"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("matches checked-in output", func(t *testing.T) {
+		dir := filepath.Join("internal", "example")
+		pkg, err := loadPackage(dir)
+		require.NoError(t, err)
+		src, err := generate(pkg, []string{"User"}, true, "github.com/calumari/kino/internal/jsontext")
+		require.NoError(t, err)
+		got, err := format.Source(src)
+		require.NoError(t, err)
+		want, err := os.ReadFile(filepath.Join(dir, "example_kino.go"))
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got), "run go generate ./cmd/kinogen/...")
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		pkg, err := loadPackage(filepath.Join("internal", "example"))
+		require.NoError(t, err)
+		_, err = generate(pkg, []string{"Missing"}, false, defaultJSONText)
+		require.Error(t, err)
+	})
+
+	t.Run("external inline rejected", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nimport \"time\"\n\ntype A struct{ time.Time }\n"), 0o644))
+		pkg, err := loadPackage(dir)
+		require.NoError(t, err)
+		_, err = generate(pkg, nil, false, defaultJSONText)
+		require.ErrorContains(t, err, "cannot inline time.Time")
+	})
+
+	t.Run("self-marshaling types keep their encoding", func(t *testing.T) {
+		dir := t.TempDir()
+		src := `package a
+
+import "time"
+
+type A struct{ X int }
+
+func (A) MarshalJSON() ([]byte, error) { return []byte("1"), nil }
+
+type B struct{ A }
+
+type C struct {
+	time.Time ` + "`json:\"t\"`" + `
+}
+
+type D struct {
+	A A ` + "`json:\"a\"`" + `
+	Y int ` + "`json:\"y\"`" + `
+}
+`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644))
+		pkg, err := loadPackage(dir)
+		require.NoError(t, err)
+		out, err := generate(pkg, nil, true, defaultJSONText)
+		require.NoError(t, err)
+		require.NotContains(t, string(out), "func (u *A) MarshalMasked")
+		require.NotContains(t, string(out), "func (u *B) MarshalMasked")
+		require.NotContains(t, string(out), "func (u *C) MarshalMasked")
+		require.Contains(t, string(out), "func (u *D) MarshalMasked")
+	})
+}
+
+func TestGeneratedAccessors(t *testing.T) {
+	u := example.UserMask
+	t.Run("paths", func(t *testing.T) {
+		require.Equal(t, "meta.plan", u.Meta().Plan().String())
+		require.Equal(t, "address.city", u.Address().City().String())
+		require.Equal(t, "id", u.ID().String())
+		require.Equal(t, "path", u.PathField().String())
+	})
+
+	t.Run("include", func(t *testing.T) {
+		m := kino.Include(u.ID(), u.Meta().Plan(), u.Tags())
+		require.Equal(t, "id,meta:(plan),tags", m.String())
+		var v example.User
+		v.ID, v.Name = 1, "Ada"
+		v.Meta = &example.Meta{Plan: "pro", Internal: "x"}
+		out, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":1,"meta":{"plan":"pro"},"tags":[]}`, string(out))
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		m := kino.Exclude(u.Meta().Internal(), u.Tags().Internal())
+		require.Equal(t, "*,meta:(-internal),tags:(-internal)", m.String())
+		require.NoError(t, m.ValidateFor(reflect.TypeFor[example.User]()))
+	})
+}
+
+func TestGeneratedMarshalMasked(t *testing.T) {
+	var u example.User
+	u.ID, u.Name, u.Path = 1, "Ada", "/ada"
+	u.Meta = &example.Meta{Plan: "pro", Internal: "x"}
+	u.Tags = []example.Meta{{Plan: "a", Internal: "y"}}
+	u.Avatar = []byte("png")
+	u.Address.City = "London"
+
+	for _, expr := range []string{"id,meta:(plan)", "-meta,-email", "-meta:(plan),tags:(internal)", "address:(city),avatar", "nothing"} {
+		t.Run(expr, func(t *testing.T) {
+			m, err := kino.ParseMask(expr)
+			require.NoError(t, err)
+			for _, v := range []any{u, &u, []example.User{u}} {
+				got, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m)))
+				require.NoError(t, err)
+				// WithStats disables generated code.
+				var stats kino.Stats
+				want, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m, kino.WithStats(&stats))))
+				require.NoError(t, err)
+				require.Equal(t, string(want), string(got))
+			}
+		})
+	}
+
+	t.Run("caller options", func(t *testing.T) {
+		m, err := kino.ParseMask("id,name")
+		require.NoError(t, err)
+		got, err := json.Marshal(&u, json.StringifyNumbers(true), json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"1","name":"Ada"}`, string(got))
+	})
+}
@@ -0,0 +1,28 @@
+// Package example holds the structs used to test kinogen output.
+package example
+
+//go:generate go run github.com/calumari/kino/cmd/kinogen -type User -marshal -jsontext github.com/calumari/kino/internal/jsontext
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type Meta struct {
+	Plan     string `json:"plan"`
+	Internal string `json:"internal"`
+}
+
+type User struct {
+	Base
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	SSN     string `json:"-"`
+	Meta    *Meta  `json:"meta"`
+	Tags    []Meta `json:"tags"`
+	Avatar  []byte `json:"avatar"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+	Path   string `json:"path"`
+	secret string
+}
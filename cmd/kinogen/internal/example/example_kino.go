@@ -0,0 +1,156 @@
+// Code generated by kinogen; DO NOT EDIT.
+
+package example
+
+import (
+	"github.com/calumari/kino/internal/jsontext"
+
+	"github.com/calumari/kino"
+)
+
+// UserMask is the root of the typed field paths of User.
+var UserMask = UserFields{}
+
+// UserFields selects fields of User.
+type UserFields struct{ kino.FieldPath }
+
+// ID selects the "id" field.
+func (f UserFields) ID() kino.FieldPath { return f.Child("id") }
+
+// Name selects the "name" field.
+func (f UserFields) Name() kino.FieldPath { return f.Child("name") }
+
+// Email selects the "email" field.
+func (f UserFields) Email() kino.FieldPath { return f.Child("email") }
+
+// Meta selects the "meta" field.
+func (f UserFields) Meta() MetaFields { return MetaFields{f.Child("meta")} }
+
+// Tags selects the "tags" field.
+func (f UserFields) Tags() MetaFields { return MetaFields{f.Child("tags")} }
+
+// Avatar selects the "avatar" field.
+func (f UserFields) Avatar() kino.FieldPath { return f.Child("avatar") }
+
+// Address selects the "address" field.
+func (f UserFields) Address() UserAddressFields { return UserAddressFields{f.Child("address")} }
+
+// PathField selects the "path" field.
+func (f UserFields) PathField() kino.FieldPath { return f.Child("path") }
+
+// MarshalMasked writes u projected by m, as kino.MarshalWithMask would.
+func (u *User) MarshalMasked(enc *jsontext.Encoder, m *kino.Mask) error {
+	if u == nil {
+		return enc.WriteToken(jsontext.Null)
+	}
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if keep, _ := m.Resolve("id"); keep {
+		if err := enc.WriteToken(jsontext.String("id")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.Int(int64(u.Base.ID))); err != nil {
+			return err
+		}
+	}
+	if keep, _ := m.Resolve("name"); keep {
+		if err := enc.WriteToken(jsontext.String("name")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(u.Name)); err != nil {
+			return err
+		}
+	}
+	if keep, _ := m.Resolve("email"); keep && u.Email != "" {
+		if err := enc.WriteToken(jsontext.String("email")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(u.Email)); err != nil {
+			return err
+		}
+	}
+	if keep, sub := m.Resolve("meta"); keep {
+		if err := enc.WriteToken(jsontext.String("meta")); err != nil {
+			return err
+		}
+		if err := kino.MarshalMasked(enc, u.Meta, sub); err != nil {
+			return err
+		}
+	}
+	if keep, sub := m.Resolve("tags"); keep {
+		if err := enc.WriteToken(jsontext.String("tags")); err != nil {
+			return err
+		}
+		if err := kino.MarshalMasked(enc, &u.Tags, sub); err != nil {
+			return err
+		}
+	}
+	if keep, sub := m.Resolve("avatar"); keep {
+		if err := enc.WriteToken(jsontext.String("avatar")); err != nil {
+			return err
+		}
+		if err := kino.MarshalMasked(enc, &u.Avatar, sub); err != nil {
+			return err
+		}
+	}
+	if keep, sub := m.Resolve("address"); keep {
+		if err := enc.WriteToken(jsontext.String("address")); err != nil {
+			return err
+		}
+		if err := kino.MarshalMasked(enc, &u.Address, sub); err != nil {
+			return err
+		}
+	}
+	if keep, _ := m.Resolve("path"); keep {
+		if err := enc.WriteToken(jsontext.String("path")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(u.Path)); err != nil {
+			return err
+		}
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
+
+// MetaFields selects fields of Meta.
+type MetaFields struct{ kino.FieldPath }
+
+// Plan selects the "plan" field.
+func (f MetaFields) Plan() kino.FieldPath { return f.Child("plan") }
+
+// Internal selects the "internal" field.
+func (f MetaFields) Internal() kino.FieldPath { return f.Child("internal") }
+
+// MarshalMasked writes u projected by m, as kino.MarshalWithMask would.
+func (u *Meta) MarshalMasked(enc *jsontext.Encoder, m *kino.Mask) error {
+	if u == nil {
+		return enc.WriteToken(jsontext.Null)
+	}
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if keep, _ := m.Resolve("plan"); keep {
+		if err := enc.WriteToken(jsontext.String("plan")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(u.Plan)); err != nil {
+			return err
+		}
+	}
+	if keep, _ := m.Resolve("internal"); keep {
+		if err := enc.WriteToken(jsontext.String("internal")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(u.Internal)); err != nil {
+			return err
+		}
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
+
+// UserAddressFields selects fields of User.Address.
+type UserAddressFields struct{ kino.FieldPath }
+
+// City selects the "city" field.
+func (f UserAddressFields) City() kino.FieldPath { return f.Child("city") }
@@ -0,0 +1,80 @@
+// Command kinogen generates a typed field path API for Go structs, so that
+// masks built in Go code are checked by the compiler:
+//
+//	//go:generate go run github.com/calumari/kino/cmd/kinogen -type User
+//
+// For every named struct type T it emits a TFields type and a TMask root value
+// with one method per JSON-visible field, e.g.
+//
+//	mask := kino.Include(UserMask.ID(), UserMask.Meta().Plan())
+//
+// Nested structs declared in the same package (directly, behind pointers, or
+// as slice and array elements) and anonymous struct fields get their own
+// accessor types. Field names follow encoding/json/v2: json tag names, `-` and
+// unexported fields skipped, embedded and `inline` structs flattened.
+//
+// With -marshal, every named struct reached also gets a
+//
+//	func (u *User) MarshalMasked(enc *jsontext.Encoder, m *kino.Mask) error
+//
+// method, which kino.MarshalWithMask uses instead of its reflective projection.
+// Scalar fields are written directly and nested values are delegated to
+// kino.MarshalMasked. Structs with fields whose encoding/json/v2 semantics the
+// generated code cannot reproduce (`string` and `format` tag options,
+// omitempty or omitzero on opaque types, fields promoted through embedded
+// pointers) are reported and keep using the generic path, as do types with
+// MarshalJSONTo, MarshalJSON or MarshalText methods of their own or promoted
+// from embedded fields. At run time the generic path is also taken when the
+// encoder sets options such as json.StringifyNumbers. The methods import
+// github.com/go-json-experiment/json/jsontext; pass
+// -jsontext encoding/json/jsontext when kino is built with GOEXPERIMENT=jsonv2.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names; all exported structs when empty")
+	output := flag.String("output", "", "output file name; default <package>_kino.go in the source directory")
+	marshal := flag.Bool("marshal", false, "also generate MarshalMasked methods used by kino.MarshalWithMask")
+	jsontextPath := flag.String("jsontext", defaultJSONText, "import path of the jsontext package used by MarshalMasked methods")
+	flag.Parse()
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+	if err := run(dir, types, *output, *marshal, *jsontextPath); err != nil {
+		fmt.Fprintln(os.Stderr, "kinogen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, types []string, output string, marshal bool, jsontextPath string) error {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg, types, marshal, jsontextPath)
+	if err != nil {
+		return err
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("format generated code: %w", err)
+	}
+	if output == "" {
+		output = filepath.Join(dir, pkg.name+"_kino.go")
+	}
+	return os.WriteFile(output, formatted, 0o644)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"strings"
+)
+
+// scalarTokens maps builtin scalar types to the jsontext constructor writing
+// them directly, with the conversion it needs.
+var scalarTokens = map[string]string{
+	"string":  "jsontext.String(%s)",
+	"bool":    "jsontext.Bool(%s)",
+	"float64": "jsontext.Float(%s)",
+	"int":     "jsontext.Int(int64(%s))",
+	"int8":    "jsontext.Int(int64(%s))",
+	"int16":   "jsontext.Int(int64(%s))",
+	"int32":   "jsontext.Int(int64(%s))",
+	"int64":   "jsontext.Int(%s)",
+	"uint":    "jsontext.Uint(uint64(%s))",
+	"uint8":   "jsontext.Uint(uint64(%s))",
+	"uint16":  "jsontext.Uint(uint64(%s))",
+	"uint32":  "jsontext.Uint(uint64(%s))",
+	"uint64":  "jsontext.Uint(%s)",
+}
+
+// zeroLiterals holds the zero value literal of the builtin scalar types.
+var zeroLiterals = map[string]string{"string": `""`, "bool": "false"}
+
+// emitMarshal writes the MarshalMasked method of the named struct goType, or
+// reports why the type keeps using the generic projection.
+func (g *generator) emitMarshal(goType string, fields []field) {
+	// MarshalMasked would take precedence over the type's own encoding.
+	if m := g.pkg.selfMarshaler(goType, make(map[string]bool)); m != "" {
+		fmt.Fprintf(os.Stderr, "kinogen: %s: no MarshalMasked: marshals itself through %s\n", goType, m)
+		return
+	}
+	var body strings.Builder
+	for _, f := range fields {
+		code, err := marshalField(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "kinogen: %s: no MarshalMasked: field %s: %v\n", goType, f.goName, err)
+			return
+		}
+		body.WriteString(code)
+	}
+	fmt.Fprintf(&g.buf, "\n// MarshalMasked writes u projected by m, as kino.MarshalWithMask would.\n")
+	fmt.Fprintf(&g.buf, "func (u *%s) MarshalMasked(enc *jsontext.Encoder, m *kino.Mask) error {\n", goType)
+	fmt.Fprintf(&g.buf, "if u == nil {\nreturn enc.WriteToken(jsontext.Null)\n}\n")
+	fmt.Fprintf(&g.buf, "if err := enc.WriteToken(jsontext.BeginObject); err != nil {\nreturn err\n}\n")
+	g.buf.WriteString(body.String())
+	fmt.Fprintf(&g.buf, "return enc.WriteToken(jsontext.EndObject)\n}\n")
+}
+
+// marshalField returns the statements writing field f of u when kept by m.
+func marshalField(f field) (string, error) {
+	if f.embedPtr {
+		return "", fmt.Errorf("promoted through an embedded pointer")
+	}
+	if hasOption(f.opts, "string") || strings.Contains(f.opts, "format:") {
+		return "", fmt.Errorf("unsupported tag options %q", f.opts)
+	}
+	expr := "u." + strings.Join(f.sel, ".")
+	cond, err := omitCondition(f, expr)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	ident, _ := f.typ.(*ast.Ident)
+	if ident != nil && scalarTokens[ident.Name] != "" {
+		fmt.Fprintf(&b, "if keep, _ := m.Resolve(%q); keep%s {\n", f.jsonName, cond)
+		writeKey(&b, f.jsonName)
+		fmt.Fprintf(&b, "if err := enc.WriteToken(%s); err != nil {\nreturn err\n}\n}\n", fmt.Sprintf(scalarTokens[ident.Name], expr))
+		return b.String(), nil
+	}
+	arg := "&" + expr
+	if _, ok := f.typ.(*ast.StarExpr); ok {
+		arg = expr // *T implements kino.MaskedMarshaler itself
+	}
+	fmt.Fprintf(&b, "if keep, sub := m.Resolve(%q); keep%s {\n", f.jsonName, cond)
+	writeKey(&b, f.jsonName)
+	fmt.Fprintf(&b, "if err := kino.MarshalMasked(enc, %s, sub); err != nil {\nreturn err\n}\n}\n", arg)
+	return b.String(), nil
+}
+
+// omitCondition returns the extra condition (starting with " && ") under which
+// field f is written, mirroring the omitempty and omitzero tag options.
+func omitCondition(f field, expr string) (string, error) {
+	var conds []string
+	if hasOption(f.opts, "omitzero") {
+		switch t := f.typ.(type) {
+		case *ast.Ident:
+			zero, ok := zeroLiterals[t.Name]
+			if !ok && scalarTokens[t.Name] != "" {
+				zero, ok = "0", true
+			}
+			if !ok {
+				return "", fmt.Errorf("omitzero on %s", t.Name)
+			}
+			conds = append(conds, expr+" != "+zero)
+		case *ast.StarExpr, *ast.MapType, *ast.InterfaceType:
+			conds = append(conds, expr+" != nil")
+		case *ast.ArrayType:
+			if t.Len != nil {
+				return "", fmt.Errorf("omitzero on array")
+			}
+			conds = append(conds, expr+" != nil")
+		default:
+			return "", fmt.Errorf("omitzero on %T", f.typ)
+		}
+	}
+	if hasOption(f.opts, "omitempty") {
+		switch t := f.typ.(type) {
+		case *ast.Ident:
+			if t.Name == "string" {
+				conds = append(conds, expr+` != ""`)
+			} else if scalarTokens[t.Name] == "" {
+				return "", fmt.Errorf("omitempty on %s", t.Name)
+			}
+			// Numbers and booleans are never empty.
+		case *ast.MapType:
+			conds = append(conds, "len("+expr+") != 0")
+		case *ast.ArrayType:
+			if t.Len != nil {
+				return "", fmt.Errorf("omitempty on array")
+			}
+			conds = append(conds, "len("+expr+") != 0")
+		default:
+			return "", fmt.Errorf("omitempty on %T", f.typ)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " && " + strings.Join(conds, " && "), nil
+}
+
+// writeKey writes the statement emitting the member name.
+func writeKey(b *strings.Builder, name string) {
+	fmt.Fprintf(b, "if err := enc.WriteToken(jsontext.String(%q)); err != nil {\nreturn err\n}\n", name)
+}
@@ -0,0 +1,83 @@
+package kino
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jsonv2 "github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// CompactMask is a Mask whose JSON form is the compact expression string, e.g.
+// "a,c:(d,-e)", instead of nested objects. Convert with (*CompactMask)(m) to
+// store masks in config files or API schemas; decoding accepts both the
+// string and the object form. Masks with field names the expression syntax
+// cannot represent fail to marshal; keep those in the object form.
+type CompactMask Mask
+
+// MarshalJSON encodes c as its expression string.
+func (c *CompactMask) MarshalJSON() ([]byte, error) {
+	s, err := compactString((*Mask)(c))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON decodes either an expression string or the object form.
+func (c *CompactMask) UnmarshalJSON(data []byte) error {
+	return (*Mask)(c).UnmarshalJSON(data)
+}
+
+// CompactMaskMarshalers returns a json.Marshalers helper that encodes every
+// Mask as its expression string instead of nested objects. A nil *Mask is
+// written as null; masks with field names the expression syntax cannot
+// represent fail to marshal, as with CompactMask.
+func CompactMaskMarshalers() *jsonv2.Marshalers {
+	return jsonv2.MarshalToFunc(func(enc *jsontext.Encoder, m *Mask) error {
+		if m == nil {
+			return enc.WriteToken(jsontext.Null)
+		}
+		s, err := compactString(m)
+		if err != nil {
+			return err
+		}
+		return enc.WriteToken(jsontext.String(s))
+	})
+}
+
+// compactString returns the expression string of m, or an error when a field
+// name would not survive ParseMask: names that are empty, contain one of
+// ",():", start with '-' or have surrounding whitespace.
+func compactString(m *Mask) (string, error) {
+	if err := checkCompact(m); err != nil {
+		return "", err
+	}
+	return m.String(), nil
+}
+
+func checkCompact(m *Mask) error {
+	if m == nil {
+		return nil
+	}
+	for _, name := range sortedKeys(m) {
+		if name == "" || strings.ContainsAny(name, ",():") || name[0] == '-' || strings.TrimSpace(name) != name {
+			return fmt.Errorf("field %q cannot be written as a mask expression", name)
+		}
+		if err := checkCompact(m.Fields[name].Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCompact replaces *m with the mask parsed from the expression s.
+func parseCompact(m *Mask, s string) error {
+	parsed, err := ParseMask(s)
+	if err != nil {
+		return err
+	}
+	*m = *parsed
+	return nil
+}
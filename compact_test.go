@@ -0,0 +1,83 @@
+package kino_test
+
+import (
+	stdjson "encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestCompactMask(t *testing.T) {
+	m, err := kino.ParseMask("a,c:(d,-e),-z:(x)")
+	require.NoError(t, err)
+
+	t.Run("legacy marshal string", func(t *testing.T) {
+		data, err := stdjson.Marshal((*kino.CompactMask)(m))
+		require.NoError(t, err)
+		require.Equal(t, `"a,c:(d,-e),-z:(x)"`, string(data))
+	})
+
+	t.Run("legacy unmarshal either form", func(t *testing.T) {
+		for _, data := range []string{`"a,c:(d,-e),-z:(x)"`, `{"a":true,"c":{"d":true,"e":false},"-z":{"x":true}}`} {
+			var c kino.CompactMask
+			require.NoError(t, stdjson.Unmarshal([]byte(data), &c))
+			require.Equal(t, m.String(), (*kino.Mask)(&c).String())
+
+			var m2 kino.Mask
+			require.NoError(t, stdjson.Unmarshal([]byte(data), &m2))
+			require.Equal(t, m.String(), m2.String())
+		}
+	})
+
+	t.Run("legacy unmarshal invalid expression", func(t *testing.T) {
+		var m2 kino.Mask
+		require.Error(t, stdjson.Unmarshal([]byte(`"a,"`), &m2))
+	})
+
+	t.Run("marshalers string", func(t *testing.T) {
+		type config struct {
+			Mask *kino.Mask `json:"mask"`
+			None *kino.Mask `json:"none"`
+		}
+		data, err := json.Marshal(config{Mask: m}, json.WithMarshalers(kino.CompactMaskMarshalers()))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"mask":"a,c:(d,-e),-z:(x)","none":null}`, string(data))
+
+		var cfg config
+		require.NoError(t, json.Unmarshal(data, &cfg, json.WithUnmarshalers(kino.MaskUnmarshalers())))
+		require.Equal(t, m.String(), cfg.Mask.String())
+		require.Nil(t, cfg.None)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		m2, err := kino.ParseMask("a b,c:(*,-d),-e")
+		require.NoError(t, err)
+		data, err := stdjson.Marshal((*kino.CompactMask)(m2))
+		require.NoError(t, err)
+		var c kino.CompactMask
+		require.NoError(t, stdjson.Unmarshal(data, &c))
+		require.Equal(t, m2, (*kino.Mask)(&c))
+	})
+
+	t.Run("unrepresentable field names", func(t *testing.T) {
+		for _, name := range []string{"a,b", "a:b", "f(x)", "-a", " a", ""} {
+			// The object form keeps any name.
+			var m2 kino.Mask
+			require.NoError(t, stdjson.Unmarshal([]byte(`{"c":{`+strconv.Quote(name)+`:true}}`), &m2))
+
+			_, err := stdjson.Marshal((*kino.CompactMask)(&m2))
+			require.ErrorContains(t, err, "cannot be written as a mask expression", name)
+			_, err = json.Marshal(&m2, json.WithMarshalers(kino.CompactMaskMarshalers()))
+			require.ErrorContains(t, err, "cannot be written as a mask expression", name)
+		}
+	})
+
+	t.Run("unmarshalers invalid expression", func(t *testing.T) {
+		var m2 kino.Mask
+		require.Error(t, json.Unmarshal([]byte(`"a:("`), &m2, json.WithUnmarshalers(kino.MaskUnmarshalers())))
+	})
+}
@@ -0,0 +1,78 @@
+package kino
+
+import (
+	"context"
+
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying mask m, typically the mask parsed
+// from a request's `fields` parameter. Storing a nil mask records that the
+// request asked for no projection.
+func NewContext(ctx context.Context, m *Mask) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the mask carried by ctx. ok is false when ctx carries no
+// mask; the mask itself may be nil if one was stored explicitly.
+func FromContext(ctx context.Context) (m *Mask, ok bool) {
+	m, ok = ctx.Value(contextKey{}).(*Mask)
+	return m, ok
+}
+
+// MarshalWithContext returns MarshalWithMask marshalers for the mask carried by
+// ctx layered on top of def. The layers are combined with
+// def.Overlay(request): entries of def take precedence (so server-side
+// exclusions cannot be lifted by a client, not even by naming children of an
+// excluded field) and the request mask contributes
+// every field def does not mention. A missing or nil request mask uses def
+// alone; when both are nil values are marshaled unchanged.
+//
+// The mask is resolved when MarshalWithContext is called, so the marshalers
+// belong to a single request. To install marshalers once, use
+// ContextMarshalers and wrap each response with InContext.
+func MarshalWithContext(ctx context.Context, def *Mask, opts ...Option) *json.Marshalers {
+	return MarshalWithMask(layerContext(ctx, def), opts...)
+}
+
+// contextValue is a value marshaled under the mask carried by ctx.
+type contextValue struct {
+	ctx context.Context
+	v   any
+}
+
+// InContext wraps v for marshaling with ContextMarshalers, which project it
+// with the mask carried by ctx.
+func InContext(ctx context.Context, v any) any {
+	return contextValue{ctx: ctx, v: v}
+}
+
+// ContextMarshalers returns marshalers that can be installed once, e.g. in a
+// shared response writer: every value wrapped with InContext is projected with
+// the mask carried by its context layered on top of def, exactly as
+// MarshalWithContext does. Other values are marshaled unchanged.
+func ContextMarshalers(def *Mask, opts ...Option) *json.Marshalers {
+	o := newOptions(opts)
+	return json.MarshalToFunc(func(enc *jsontext.Encoder, cv contextValue) error {
+		m := layerContext(cv.ctx, def)
+		if m == nil {
+			return json.MarshalEncode(enc, cv.v)
+		}
+		return (&maskMarshaler{m: m, o: o, root: true}).apply(enc, cv.v)
+	})
+}
+
+// layerContext returns the mask carried by ctx layered on top of def.
+func layerContext(ctx context.Context, def *Mask) *Mask {
+	req, _ := FromContext(ctx)
+	switch {
+	case req == nil:
+		return def
+	case def == nil:
+		return req
+	}
+	return def.Overlay(req)
+}
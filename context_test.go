@@ -0,0 +1,130 @@
+package kino_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestContext(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		got, ok := kino.FromContext(kino.NewContext(context.Background(), m))
+		require.True(t, ok)
+		require.Same(t, m, got)
+	})
+
+	t.Run("absent mask", func(t *testing.T) {
+		got, ok := kino.FromContext(context.Background())
+		require.False(t, ok)
+		require.Nil(t, got)
+	})
+
+	t.Run("explicit nil mask", func(t *testing.T) {
+		got, ok := kino.FromContext(kino.NewContext(context.Background(), nil))
+		require.True(t, ok)
+		require.Nil(t, got)
+	})
+}
+
+func TestMarshalWithContext(t *testing.T) {
+	marshal := func(t *testing.T, ctx context.Context, def *kino.Mask) string {
+		t.Helper()
+		out, err := json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithContext(ctx, def)))
+		require.NoError(t, err)
+		return string(out)
+	}
+	parse := func(t *testing.T, expr string) *kino.Mask {
+		t.Helper()
+		m, err := kino.ParseMask(expr)
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("no masks marshals unchanged", func(t *testing.T) {
+		require.JSONEq(t, `{"a":"va","b":"vb","c":{"d":1,"e":2},"z":{"x":10,"y":20}}`, marshal(t, context.Background(), nil))
+	})
+
+	t.Run("request mask only", func(t *testing.T) {
+		ctx := kino.NewContext(context.Background(), parse(t, "a,c:(d)"))
+		require.JSONEq(t, `{"a":"va","c":{"d":1}}`, marshal(t, ctx, nil))
+	})
+
+	t.Run("default mask only", func(t *testing.T) {
+		require.JSONEq(t, `{"a":"va","b":"vb","z":{"x":10,"y":20}}`, marshal(t, context.Background(), parse(t, "-c")))
+	})
+
+	t.Run("nil request mask uses default", func(t *testing.T) {
+		ctx := kino.NewContext(context.Background(), nil)
+		require.JSONEq(t, `{"a":"va"}`, marshal(t, ctx, parse(t, "a")))
+	})
+
+	t.Run("default exclusions win over request", func(t *testing.T) {
+		ctx := kino.NewContext(context.Background(), parse(t, "a,b,c"))
+		require.JSONEq(t, `{"a":"va","c":{"d":1,"e":2}}`, marshal(t, ctx, parse(t, "-b")))
+	})
+
+	t.Run("request cannot re-include children of excluded fields", func(t *testing.T) {
+		ctx := kino.NewContext(context.Background(), parse(t, "a,c:(d)"))
+		require.JSONEq(t, `{"a":"va"}`, marshal(t, ctx, parse(t, "-c")))
+	})
+}
+
+func TestContextMarshalers(t *testing.T) {
+	def, err := kino.ParseMask("-b")
+	require.NoError(t, err)
+	// Installed once and shared by every request.
+	opts := json.WithMarshalers(kino.ContextMarshalers(def))
+
+	marshal := func(t *testing.T, v any) string {
+		t.Helper()
+		out, err := json.Marshal(v, opts)
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	t.Run("mask read per marshal call", func(t *testing.T) {
+		m1, err := kino.ParseMask("a,b")
+		require.NoError(t, err)
+		m2, err := kino.ParseMask("c:(d)")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va"}`, marshal(t, kino.InContext(kino.NewContext(context.Background(), m1), buildSample())))
+		require.JSONEq(t, `{"c":{"d":1}}`, marshal(t, kino.InContext(kino.NewContext(context.Background(), m2), buildSample())))
+	})
+
+	t.Run("no request mask uses default", func(t *testing.T) {
+		require.JSONEq(t, `{"a":"va","c":{"d":1,"e":2},"z":{"x":10,"y":20}}`, marshal(t, kino.InContext(context.Background(), buildSample())))
+	})
+
+	t.Run("request cannot re-include children of excluded fields", func(t *testing.T) {
+		def, err := kino.ParseMask("-z")
+		require.NoError(t, err)
+		req, err := kino.ParseMask("a,z:(x)")
+		require.NoError(t, err)
+		out, err := json.Marshal(kino.InContext(kino.NewContext(context.Background(), req), buildSample()), json.WithMarshalers(kino.ContextMarshalers(def)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va"}`, string(out))
+	})
+
+	t.Run("no masks marshals unchanged", func(t *testing.T) {
+		out, err := json.Marshal(kino.InContext(context.Background(), buildSample()), json.WithMarshalers(kino.ContextMarshalers(nil)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va","b":"vb","c":{"d":1,"e":2},"z":{"x":10,"y":20}}`, string(out))
+	})
+
+	t.Run("unwrapped values unchanged", func(t *testing.T) {
+		require.JSONEq(t, `{"a":"va","b":"vb","c":{"d":1,"e":2},"z":{"x":10,"y":20}}`, marshal(t, buildSample()))
+	})
+
+	t.Run("nested wrapped value", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		resp := map[string]any{"data": kino.InContext(kino.NewContext(context.Background(), m), buildSample())}
+		require.JSONEq(t, `{"data":{"a":"va"}}`, marshal(t, resp))
+	})
+}
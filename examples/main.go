@@ -1,3 +1,8 @@
+//go:build !goexperiment.jsonv2
+
+// The example uses github.com/go-json-experiment/json directly, so it is only
+// built when kino targets that package.
+
 package main
 
 import (
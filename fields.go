@@ -0,0 +1,123 @@
+package kino
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/calumari/kino/internal/json"
+)
+
+// jsonField describes a Go struct field as seen by encoding/json/v2.
+type jsonField struct {
+	name  string       // JSON object member name
+	index []int        // index sequence for reflect.Value.FieldByIndex
+	typ   reflect.Type // Go type of the field
+	tag   reflect.StructTag
+}
+
+var jsonFieldsCache sync.Map // map[reflect.Type][]jsonField
+
+// jsonFields returns the JSON-visible fields of struct type t in declaration
+// order. Names follow encoding/json/v2: the json tag name when present, the Go
+// field name otherwise. Unexported and `json:"-"` fields are skipped, while
+// embedded structs without an explicit name (and structs tagged with one of
+// json.EmbedOptions) are flattened into their parent. A map or jsontext.Value
+// tagged that way holds the members no other field claims, so it is not a
+// member itself and is left out. When several fields share a name the
+// shallowest one wins; a tie at that depth goes to the only field naming
+// itself in its tag, and otherwise the name is ambiguous and dropped. The
+// result is cached per type and must not be mutated.
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+	type candidate struct {
+		jsonField
+		tagged bool // name comes from the json tag
+	}
+	var fields []candidate
+	var visit func(t reflect.Type, index []int, visiting map[reflect.Type]bool)
+	visit = func(t reflect.Type, index []int, visiting map[reflect.Type]bool) {
+		if visiting[t] {
+			return
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			idx := append(append([]int(nil), index...), i)
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			embed := slices.ContainsFunc(json.EmbedOptions, func(o string) bool { return hasTagOption(opts, o) })
+			if (sf.Anonymous && name == "" || embed) && ft.Kind() == reflect.Struct {
+				if sf.IsExported() || sf.Anonymous {
+					visit(ft, idx, visiting)
+				}
+				continue
+			}
+			if embed {
+				continue // fallback for unknown members, never a member itself
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			tagged := name != ""
+			if !tagged {
+				name = sf.Name
+			}
+			fields = append(fields, candidate{jsonField{name: name, index: idx, typ: sf.Type, tag: sf.Tag}, tagged})
+		}
+	}
+	visit(t, nil, make(map[reflect.Type]bool))
+
+	// Pick the dominant field for every name: the only one at the shallowest
+	// depth, or the only tagged one there. Without one the name is dropped.
+	type rank struct{ depth, count, tagged int }
+	ranks := make(map[string]rank)
+	for _, f := range fields {
+		r, ok := ranks[f.name]
+		if !ok || len(f.index) < r.depth {
+			r = rank{depth: len(f.index)}
+		} else if len(f.index) > r.depth {
+			continue
+		}
+		r.count++
+		if f.tagged {
+			r.tagged++
+		}
+		ranks[f.name] = r
+	}
+	res := make([]jsonField, 0, len(fields))
+	for _, f := range fields {
+		r := ranks[f.name]
+		if len(f.index) != r.depth {
+			continue
+		}
+		if r.count == 1 || r.tagged == 1 && f.tagged {
+			res = append(res, f.jsonField)
+		}
+	}
+	cached, _ := jsonFieldsCache.LoadOrStore(t, res)
+	return cached.([]jsonField)
+}
+
+// hasTagOption reports whether the comma separated tag options contain opt.
+func hasTagOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
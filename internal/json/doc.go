@@ -0,0 +1,10 @@
+// Package json selects the encoding/json/v2 implementation kino is built
+// against: the standard library package when the goexperiment.jsonv2 build tag
+// is set (GOEXPERIMENT=jsonv2), github.com/go-json-experiment/json otherwise.
+// It re-exports the subset of the API kino and its tests use, so that the
+// public kino API refers to the same types as the caller's json package.
+//
+// The standard library variant needs Go 1.27, the first release with a stable
+// encoding/json/v2; its file carries a go1.27 constraint so that vet accepts
+// the new API in a module declaring an older Go version.
+package json
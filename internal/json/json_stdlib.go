@@ -0,0 +1,64 @@
+//go:build goexperiment.jsonv2 && go1.27
+
+package json
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+)
+
+type (
+	Marshalers      = json.Marshalers
+	Unmarshalers    = json.Unmarshalers
+	Options         = json.Options
+	Marshaler       = json.Marshaler
+	MarshalerTo     = json.MarshalerTo
+	Unmarshaler     = json.Unmarshaler
+	UnmarshalerFrom = json.UnmarshalerFrom
+	SemanticError   = json.SemanticError
+)
+
+// SkipFunc may be returned by marshal and unmarshal functions to skip them.
+// The standard library uses errors.ErrUnsupported.
+var SkipFunc = errors.ErrUnsupported
+
+// EmbedOptions are the struct tag options that splice a field into its parent
+// object: the members of a struct, or the unknown members held by a map.
+// The standard library spells it embed and has no separate unknown option.
+var EmbedOptions = []string{"embed"}
+
+var (
+	Marshal          = json.Marshal
+	MarshalWrite     = json.MarshalWrite
+	MarshalEncode    = json.MarshalEncode
+	Unmarshal        = json.Unmarshal
+	UnmarshalRead    = json.UnmarshalRead
+	UnmarshalDecode  = json.UnmarshalDecode
+	WithMarshalers   = json.WithMarshalers
+	WithUnmarshalers = json.WithUnmarshalers
+	JoinMarshalers   = json.JoinMarshalers
+	JoinUnmarshalers = json.JoinUnmarshalers
+
+	MatchCaseInsensitiveNames = json.MatchCaseInsensitiveNames
+	RejectUnknownMembers      = json.RejectUnknownMembers
+	StringifyNumbers          = json.StringifyNumbers
+	FormatNilSliceAsNull      = json.FormatNilSliceAsNull
+	FormatNilMapAsNull        = json.FormatNilMapAsNull
+	OmitZeroStructFields      = json.OmitZeroStructFields
+)
+
+// GetOption wraps json.GetOption.
+func GetOption[T any](opts Options, setter func(T) Options) (T, bool) {
+	return json.GetOption(opts, setter)
+}
+
+// MarshalToFunc wraps json.MarshalToFunc.
+func MarshalToFunc[T any](fn func(*jsontext.Encoder, T) error) *Marshalers {
+	return json.MarshalToFunc(fn)
+}
+
+// UnmarshalFromFunc wraps json.UnmarshalFromFunc.
+func UnmarshalFromFunc[T any](fn func(*jsontext.Decoder, T) error) *Unmarshalers {
+	return json.UnmarshalFromFunc(fn)
+}
@@ -0,0 +1,5 @@
+// Package jsontext is the jsontext counterpart of kino's internal json
+// package: the standard library encoding/json/jsontext when the
+// goexperiment.jsonv2 build tag is set (Go 1.27 or later),
+// github.com/go-json-experiment/json/jsontext otherwise.
+package jsontext
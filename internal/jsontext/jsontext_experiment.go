@@ -0,0 +1,40 @@
+//go:build !goexperiment.jsonv2
+
+package jsontext
+
+import "github.com/go-json-experiment/json/jsontext"
+
+type (
+	Encoder = jsontext.Encoder
+	Decoder = jsontext.Decoder
+	Value   = jsontext.Value
+	Token   = jsontext.Token
+	Kind    = jsontext.Kind
+	Options = jsontext.Options
+)
+
+var (
+	Null        = jsontext.Null
+	False       = jsontext.False
+	True        = jsontext.True
+	BeginObject = jsontext.BeginObject
+	EndObject   = jsontext.EndObject
+	BeginArray  = jsontext.BeginArray
+	EndArray    = jsontext.EndArray
+)
+
+var (
+	NewEncoder  = jsontext.NewEncoder
+	NewDecoder  = jsontext.NewDecoder
+	String      = jsontext.String
+	Bool        = jsontext.Bool
+	Int         = jsontext.Int
+	Uint        = jsontext.Uint
+	Float       = jsontext.Float
+	AppendQuote = jsontext.AppendQuote[string]
+
+	Multiline       = jsontext.Multiline
+	SpaceAfterColon = jsontext.SpaceAfterColon
+	SpaceAfterComma = jsontext.SpaceAfterComma
+	WithIndent      = jsontext.WithIndent
+)
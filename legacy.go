@@ -0,0 +1,36 @@
+package kino
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// Masked wraps a value for encoding/json (v1): its MarshalJSON encodes Value
+// with encoding/json and projects the result by Mask with the same semantics
+// as MarshalWithMask, so legacy code can write
+//
+//	json.NewEncoder(w).Encode(kino.Masked{Value: resp, Mask: mask})
+//
+// Value keeps its v1 encoding (struct tags, MarshalJSON methods, nil slices
+// as null); only the members the mask drops are removed. A nil Mask leaves
+// the output unchanged.
+type Masked struct {
+	Value any
+	Mask  *Mask
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Masked) MarshalJSON() ([]byte, error) {
+	src, err := json.Marshal(m.Value)
+	if err != nil || m.Mask == nil {
+		return src, err
+	}
+	var buf bytes.Buffer
+	p := newProjector(jsontext.NewDecoder(bytes.NewReader(src)), jsontext.NewEncoder(&buf), &options{})
+	if err := p.copyMasked(m.Mask); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,62 @@
+package kino_test
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestMasked(t *testing.T) {
+	t.Run("nil mask unchanged", func(t *testing.T) {
+		out, err := stdjson.Marshal(kino.Masked{Value: buildSample()})
+		require.NoError(t, err)
+		want, err := stdjson.Marshal(buildSample())
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(out))
+	})
+
+	t.Run("matches MarshalWithMask", func(t *testing.T) {
+		for _, expr := range []string{"a,c:(d)", "-b,-c:(-e)", "-z:(x)", "a,-b,c:(d,-e),-z:(x)"} {
+			m, err := kino.ParseMask(expr)
+			require.NoError(t, err)
+			want, err := json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithMask(m)))
+			require.NoError(t, err)
+			got, err := stdjson.Marshal(kino.Masked{Value: buildSample(), Mask: m})
+			require.NoError(t, err)
+			require.JSONEq(t, string(want), string(got), expr)
+		}
+	})
+
+	t.Run("encoder nested in legacy response", func(t *testing.T) {
+		m, err := kino.ParseMask("a,-z:(x)")
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, stdjson.NewEncoder(&buf).Encode(map[string]any{
+			"data": &kino.Masked{Value: buildSample(), Mask: m},
+			"html": "<b>",
+		}))
+		require.Equal(t, `{"data":{"a":"va","z":{"x":10}},"html":"\u003cb\u003e"}`+"\n", buf.String())
+	})
+
+	t.Run("value keeps v1 encoding", func(t *testing.T) {
+		m, err := kino.ParseMask("tags,name")
+		require.NoError(t, err)
+		out, err := stdjson.Marshal(kino.Masked{Value: struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+			Skip int      `json:"skip"`
+		}{Name: "n"}, Mask: m})
+		require.NoError(t, err)
+		require.Equal(t, `{"name":"n","tags":null}`, string(out))
+	})
+
+	t.Run("marshal error", func(t *testing.T) {
+		_, err := stdjson.Marshal(kino.Masked{Value: make(chan int), Mask: &kino.Mask{}})
+		require.Error(t, err)
+	})
+}
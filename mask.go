@@ -13,11 +13,21 @@ const (
 	Negative
 )
 
+// Wildcard is the field name matching every key that has no entry of its own,
+// such as the keys of a map: "extra:(*:(plan))" keeps the plan of every entry.
+const Wildcard = "*"
+
 type Node struct {
 	Op       Op
 	Children *Mask
 }
 
+// hasChildren reports whether n carries a non-empty subtree. Nodes with a nil
+// or empty Children mask behave as leaves.
+func (n *Node) hasChildren() bool {
+	return n.Children != nil && len(n.Children.Fields) > 0
+}
+
 // Mask represents a field projection tree. Fields maps field name -> Node
 // (include/exclude + optional subtree). Mode governs root semantics (whitelist
 // vs blacklist).
@@ -27,19 +37,19 @@ type Mask struct {
 	//   - Exclude includes everything except explicitly listed negative paths.
 	Mode   Op
 	Fields map[string]*Node
+	// Order lists the keys of Fields in the order they were written, as
+	// recorded by ParseMask and the JSON decoders. It is optional: keys missing
+	// from Order are treated as following it in sorted order, and entries not
+	// present in Fields are ignored.
+	Order []string
 }
 
 func (m *Mask) String() string {
 	if m == nil || len(m.Fields) == 0 {
 		return ""
 	}
-	keys := make([]string, 0, len(m.Fields))
-	for k := range m.Fields {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
 	parts := make([]string, 0, len(m.Fields))
-	for _, name := range keys {
+	for _, name := range sortedKeys(m) {
 		node := m.Fields[name]
 		prefix := ""
 		if node.Op == Negative {
@@ -54,9 +64,86 @@ func (m *Mask) String() string {
 	return strings.Join(parts, ",")
 }
 
+// sortedKeys returns the field names of m in sorted order.
+func sortedKeys(m *Mask) []string {
+	keys := make([]string, 0, len(m.Fields))
+	for k := range m.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orderedKeys returns the field names of m in mask order: the keys recorded in
+// m.Order first, then any remaining keys sorted.
+func orderedKeys(m *Mask) []string {
+	if len(m.Order) == 0 {
+		return sortedKeys(m)
+	}
+	keys := make([]string, 0, len(m.Fields))
+	listed := make(map[string]bool, len(m.Order))
+	for _, k := range m.Order {
+		if _, ok := m.Fields[k]; ok && !listed[k] {
+			listed[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == len(m.Fields) {
+		return keys
+	}
+	rest := make([]string, 0, len(m.Fields)-len(keys))
+	for k := range m.Fields {
+		if !listed[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// Resolve reports how the value stored under key is projected by m. keep is
+// false when the key must be dropped; otherwise sub is the mask to apply to the
+// value (nil means copy it verbatim). A nil mask keeps every key. The rules are
+// shared by every projector, including generated MarshalMasked methods:
+//   - Positive mode: only positive keys are kept, narrowed by their children.
+//   - Negative mode: every key is kept except simple negatives.
+//   - A negative key with children is an override in either mode: the key is
+//     kept and only its positive descendants survive (whitelist semantics).
+//   - Keys without an entry of their own use the Wildcard entry, if any.
+func (m *Mask) Resolve(key string) (keep bool, sub *Mask) {
+	if m == nil {
+		return true, nil
+	}
+	node, ok := m.lookup(key)
+	if ok && node.Op == Negative {
+		if !node.hasChildren() {
+			return false, nil
+		}
+		return true, &Mask{Mode: Positive, Fields: node.Children.Fields, Order: node.Children.Order}
+	}
+	if !ok {
+		return m.Mode == Negative, nil
+	}
+	if node.hasChildren() {
+		return true, node.Children
+	}
+	return true, nil
+}
+
+// lookup returns the node governing key: its own entry, or the Wildcard entry
+// when it has none.
+func (m *Mask) lookup(key string) (*Node, bool) {
+	if node, ok := m.Fields[key]; ok {
+		return node, true
+	}
+	node, ok := m.Fields[Wildcard]
+	return node, ok
+}
+
 // Overlay returns a new Mask that is the field-wise union of the receiver and
 // other. The receiver's existing field Ops always win; only missing fields (or
-// missing child subtrees) are taken from other. Resulting nodes are deep copies
+// missing child subtrees) are taken from other. Fields the receiver excludes
+// outright stay excluded: other's children are not attached to them. Resulting nodes are deep copies
 // (inputs are never mutated) and the root Mode of every merged node is
 // recomputed from its direct children (negative-only => Negative else
 // Positive). A nil receiver or nil other is treated as an empty mask.
@@ -67,8 +154,9 @@ func (m *Mask) Overlay(other *Mask) *Mask {
 // overlayMaskRecursive is the implementation behind Mask.Overlay. It performs a
 // structural, immutable merge of two masks:
 //   - Base fields copied first (deep clone).
-//   - Overlay fields added only when absent in base.
+//   - Overlay fields added only when absent in base, ordered after base fields.
 //   - For overlapping keys: base Op retained; children merged recursively.
+//     Negative leaves of base stay leaves.
 //   - Modes are ignored during merge and derived fresh from each merged node's
 //     direct children (deriveMode).
 //
@@ -90,12 +178,16 @@ func overlayMaskRecursive(base, overlay *Mask) *Mask {
 	for k, n := range base.Fields {
 		res.Fields[k] = cloneNode(n)
 	}
+	res.Order = orderedKeys(base)
 
-	// merge in overlay fields.
-	for k, oNode := range overlay.Fields {
+	// merge in overlay fields (appended to the order after base fields).
+	for _, k := range orderedKeys(overlay) {
+		oNode := overlay.Fields[k]
 		if existing, ok := res.Fields[k]; ok {
-			// preserve existing.Op. merge/attach children recursively.
-			if oNode.Children != nil {
+			// preserve existing.Op. merge/attach children recursively, except
+			// onto a negative leaf: children would turn the exclusion into an
+			// override that re-includes them.
+			if oNode.Children != nil && (existing.Op != Negative || existing.hasChildren()) {
 				if existing.Children == nil {
 					existing.Children = cloneMask(oNode.Children)
 				} else {
@@ -105,6 +197,7 @@ func overlayMaskRecursive(base, overlay *Mask) *Mask {
 			continue // keep base Op
 		}
 		res.Fields[k] = cloneNode(oNode)
+		res.Order = append(res.Order, k)
 	}
 	res.Mode = deriveMode(res)
 
@@ -123,6 +216,9 @@ func cloneMask(m *Mask) *Mask {
 	for k, n := range m.Fields {
 		cp.Fields[k] = cloneNode(n)
 	}
+	if m.Order != nil {
+		cp.Order = append([]string(nil), m.Order...)
+	}
 	return cp
 }
 
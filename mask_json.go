@@ -1,80 +1,50 @@
 package kino
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
+
+	"github.com/calumari/kino/internal/jsontext"
 )
 
+// MarshalJSON encodes m as nested JSON objects: leaves map to true (include)
+// or false (exclude), and subtrees to objects. A negative node with children
+// (an override such as `-z:(x)`) is written under its name prefixed with '-',
+// e.g. {"-z":{"x":true}}, so that it decodes back unchanged. It shares its
+// implementation with MarshalJSONTo.
 func (m *Mask) MarshalJSON() ([]byte, error) {
-	if m == nil || len(m.Fields) == 0 {
-		return []byte("{}"), nil
-	}
-	var walk func(mm *Mask) map[string]any
-	walk = func(mm *Mask) map[string]any {
-		x := make(map[string]any, len(mm.Fields))
-		for k, n := range mm.Fields {
-			if n.Children != nil && len(n.Children.Fields) > 0 {
-				x[k] = walk(n.Children)
-			} else {
-				x[k] = n.Op == Positive
-			}
-		}
-		return x
+	var buf bytes.Buffer
+	if err := m.MarshalJSONTo(jsontext.NewEncoder(&buf)); err != nil {
+		return nil, err
 	}
-	x := walk(m)
-	return json.Marshal(x)
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
 }
 
-// Legacy encoding/json (v1) fallback: still supports flat map[string]bool form.
+// UnmarshalJSON implements encoding/json (v1) decoding by delegating to
+// UnmarshalJSONFrom, so both JSON APIs accept the same input with the same
+// errors: nested objects, flat boolean or number leaves, '-' prefixed
+// override subtrees, and mask expression strings (see CompactMask).
 func (m *Mask) UnmarshalJSON(data []byte) error {
-	if string(data) == "null" {
-		*m = Mask{}
-		return nil
-	}
-	// Try nested object first using standard decode.
-	var generic map[string]any
-	if err := json.Unmarshal(data, &generic); err != nil {
+	dec := jsontext.NewDecoder(bytes.NewReader(data))
+	if err := m.UnmarshalJSONFrom(dec); err != nil {
 		return err
 	}
-	var build func(mm map[string]any) (*Mask, error)
-	build = func(mm map[string]any) (*Mask, error) {
-		res := &Mask{Mode: Positive, Fields: make(map[string]*Node, len(mm))}
-		for k, v := range mm {
-			switch vv := v.(type) {
-			case map[string]any:
-				child, err := build(vv)
-				if err != nil {
-					return nil, err
-				}
-				res.Fields[k] = &Node{Op: Positive, Children: child}
-			case bool:
-				op := Negative
-				if vv {
-					op = Positive
-				}
-				res.Fields[k] = &Node{Op: op}
-			case float64:
-				op := Positive
-				if vv < 0 {
-					op = Negative
-				}
-				res.Fields[k] = &Node{Op: op}
-			default:
-				return nil, fmt.Errorf("unsupported value type %T for key %q", v, k)
-			}
-		}
-
-		// Determine mode for this mask: negative-only => Negative.
-		res.Mode = deriveMode(res)
-
-		return res, nil
-	}
-	built, err := build(generic)
-	if err != nil {
+	switch _, err := dec.ReadToken(); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return fmt.Errorf("unexpected data after mask at offset %d", dec.InputOffset())
+	default:
 		return err
 	}
-	if built != nil {
-		*m = *built
+}
+
+// overrideKey splits the name of a subtree member: a leading '-' marks a
+// negative override subtree.
+func overrideKey(key string) (name string, op Op) {
+	if len(key) > 1 && key[0] == '-' {
+		return key[1:], Negative
 	}
-	return nil
+	return key, Positive
 }
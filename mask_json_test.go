@@ -2,11 +2,13 @@ package kino_test
 
 import (
 	"encoding/json"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/calumari/kino"
+	jsonv2 "github.com/calumari/kino/internal/json"
 )
 
 func TestMaskJSON(t *testing.T) {
@@ -42,4 +44,61 @@ func TestMaskJSON(t *testing.T) {
 		// Child contains only a negative => negative mode.
 		require.Equal(t, kino.Negative, child.Mode)
 	})
+	t.Run("legacy negative override round trip", func(t *testing.T) {
+		m, err := kino.ParseMask("-z:(x),-b")
+		require.NoError(t, err)
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"-z":{"x":true},"b":false}`, string(data))
+
+		var m2 kino.Mask
+		require.NoError(t, json.Unmarshal(data, &m2))
+		require.Equal(t, kino.Negative, m2.Mode)
+		require.Equal(t, m.String(), m2.String())
+	})
+
+	t.Run("legacy override duplicate rejected", func(t *testing.T) {
+		var m kino.Mask
+		require.Error(t, json.Unmarshal([]byte(`{"z":true,"-z":{"x":true}}`), &m))
+	})
+
+	t.Run("empty input reports EOF", func(t *testing.T) {
+		var m kino.Mask
+		require.ErrorIs(t, m.UnmarshalJSON([]byte("")), io.EOF)
+		require.ErrorIs(t, m.UnmarshalJSON([]byte("  ")), io.EOF)
+		require.ErrorIs(t, m.UnmarshalJSON([]byte(`{"a":`)), io.ErrUnexpectedEOF)
+	})
+}
+
+func TestMaskJSONConsistency(t *testing.T) {
+	inputs := []string{
+		`{"a":true,"a":false}`,
+		`{"z":{"x":true},"-z":{"y":true}}`,
+		`{"a":"yes"}`,
+		`[1]`,
+		`{"a":true} {}`,
+	}
+	for _, in := range inputs {
+		t.Run(in, func(t *testing.T) {
+			var m1, m2, m3 kino.Mask
+			err1 := m1.UnmarshalJSON([]byte(in))
+			err2 := jsonv2.Unmarshal([]byte(in), &m2)
+			err3 := jsonv2.Unmarshal([]byte(in), &m3, jsonv2.WithUnmarshalers(kino.MaskUnmarshalers()))
+			require.Error(t, err1)
+			require.Error(t, err2)
+			require.Error(t, err3)
+		})
+	}
+
+	t.Run("v2 methods round trip in mask order", func(t *testing.T) {
+		m, err := kino.ParseMask("z,-b:(c),a")
+		require.NoError(t, err)
+		data, err := jsonv2.Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `{"z":true,"-b":{"c":true},"a":true}`, string(data))
+		var m2 kino.Mask
+		require.NoError(t, jsonv2.Unmarshal(data, &m2))
+		require.Equal(t, m.String(), m2.String())
+		require.Equal(t, m.Order, m2.Order)
+	})
 }
@@ -3,312 +3,675 @@ package kino
 import (
 	"bytes"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
-	"github.com/go-json-experiment/json"
-	"github.com/go-json-experiment/json/jsontext"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
 )
 
-// MaskUnmarshalers returns a json.Unmarshalers helper that can decode a JSON
-// object into a Mask value. It recognises nested objects and leaf
-// booleans/numbers (negative meaning exclusion).
+// MaskUnmarshalers returns a json.Unmarshalers helper that decodes JSON into a
+// Mask value. Mask implements json.UnmarshalerFrom itself, so the helper is
+// only kept for compatibility: both decode through Mask.UnmarshalJSONFrom.
 func MaskUnmarshalers() *json.Unmarshalers {
 	return json.UnmarshalFromFunc(func(dec *jsontext.Decoder, v *Mask) error {
-		if dec.PeekKind() != '{' {
-			return json.SkipFunc
+		return v.UnmarshalJSONFrom(dec)
+	})
+}
+
+// MarshalJSONTo implements json.MarshalerTo: m is written as nested objects
+// in mask order (see MarshalJSON for the format).
+func (m *Mask) MarshalJSONTo(enc *jsontext.Encoder) error {
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if m != nil {
+		for _, k := range orderedKeys(m) {
+			n := m.Fields[k]
+			if !n.hasChildren() {
+				if err := enc.WriteToken(jsontext.String(k)); err != nil {
+					return err
+				}
+				if err := enc.WriteToken(jsontext.Bool(n.Op == Positive)); err != nil {
+					return err
+				}
+				continue
+			}
+			if n.Op == Negative {
+				k = "-" + k
+			}
+			if err := enc.WriteToken(jsontext.String(k)); err != nil {
+				return err
+			}
+			if err := n.Children.MarshalJSONTo(enc); err != nil {
+				return err
+			}
 		}
-		if _, err := dec.ReadToken(); err != nil { // consume opening '{'
-			return fmt.Errorf("read opening '{': %w", err)
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
+
+// UnmarshalJSONFrom implements json.UnmarshalerFrom and is the single decoder
+// behind UnmarshalJSON and MaskUnmarshalers. It accepts null (empty mask), a
+// mask expression string (see CompactMask), or nested objects whose leaves are
+// booleans or numbers (false or negative meaning exclusion). Nested objects
+// under a name prefixed with '-' decode as negative override subtrees.
+// Duplicate fields are rejected. The recorded Order follows the input.
+func (m *Mask) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	switch dec.PeekKind() {
+	case 'n':
+		if _, err := dec.ReadToken(); err != nil {
+			return err
 		}
-		if v.Fields == nil {
-			v.Fields = make(map[string]*Node)
+		*m = Mask{}
+		return nil
+	case '"':
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return err
 		}
-		mask := v
-
-		// Track pos/neg for this object (nested objects recurse via another
-		// invocation, so a single frame is sufficient here unlike the parser's
-		// multi-level stack during expression parsing).
-		type frame struct {
-			hasPos, hasNeg bool
+		return parseCompact(m, tok.String())
+	case '{':
+	case 0:
+		// PeekKind hides the read error; ReadToken reports it.
+		_, err := dec.ReadToken()
+		return err
+	default:
+		return fmt.Errorf("unexpected %s for mask, want object or string", dec.PeekKind())
+	}
+	if _, err := dec.ReadToken(); err != nil {
+		return fmt.Errorf("read '{': %w", err)
+	}
+	res := Mask{Mode: Positive, Fields: make(map[string]*Node)}
+	for dec.PeekKind() != '}' {
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return fmt.Errorf("read key: %w", err)
 		}
-		f := &frame{}
-		update := func(op Op) {
-			switch op {
-			case Positive:
-				f.hasPos = true
-			case Negative:
-				f.hasNeg = true
+		key := tok.String()
+		var node *Node
+		switch dec.PeekKind() {
+		case '{':
+			var op Op
+			key, op = overrideKey(key)
+			if _, exists := res.Fields[key]; exists {
+				return fmt.Errorf("duplicate field %q", key)
 			}
-		}
-		for dec.PeekKind() != '}' {
-			// read key
-			var key string
-			if err := json.UnmarshalDecode(dec, &key); err != nil {
-				return fmt.Errorf("read key: %w", err)
+			child := &Mask{}
+			if err := child.UnmarshalJSONFrom(dec); err != nil {
+				return fmt.Errorf("decode child %q: %w", key, err)
 			}
-			if _, exists := mask.Fields[key]; exists {
+			node = &Node{Op: op, Children: child}
+		default:
+			if _, exists := res.Fields[key]; exists {
 				return fmt.Errorf("duplicate field %q", key)
 			}
-
-			switch dec.PeekKind() {
-			case '{':
-				var child Mask
-				if err := json.UnmarshalDecode(dec, &child); err != nil {
-					return fmt.Errorf("decode child %q: %w", key, err)
+			val, err := dec.ReadToken()
+			if err != nil {
+				return fmt.Errorf("read value for %q: %w", key, err)
+			}
+			op := Positive
+			switch val.Kind() {
+			case 't':
+			case 'f':
+				op = Negative
+			case '0':
+				// String returns the raw JSON number text.
+				if f, err := strconv.ParseFloat(val.String(), 64); err == nil && f < 0 {
+					op = Negative
 				}
-				mask.Fields[key] = &Node{Op: Positive, Children: &child}
-				update(Positive)
 			default:
-				var raw any
-				if err := json.UnmarshalDecode(dec, &raw); err != nil {
-					return fmt.Errorf("read value for %q: %w", key, err)
-				}
-				op := Positive
-				switch v := raw.(type) {
-				case bool:
-					if !v {
-						op = Negative
-					}
-				case float64:
-					if v < 0 {
-						op = Negative
-					}
-				case int64:
-					if v < 0 {
-						op = Negative
-					}
-				case uint64:
-					// always positive
-				default:
-					return fmt.Errorf("unexpected value type %T for key %q", raw, key)
-				}
-				mask.Fields[key] = &Node{Op: op}
-				update(op)
+				return fmt.Errorf("unexpected value %s for key %q", val.Kind(), key)
 			}
+			node = &Node{Op: op}
 		}
-		if _, err := dec.ReadToken(); err != nil { // consume closing '}'
-			return fmt.Errorf("read closing '}': %w", err)
+		res.Fields[key] = node
+		res.Order = append(res.Order, key)
+	}
+	if _, err := dec.ReadToken(); err != nil {
+		return fmt.Errorf("read '}': %w", err)
+	}
+	res.Mode = deriveMode(&res)
+	*m = res
+	return nil
+}
+
+// WithMask returns a json.Marshalers helper that, when supplied to
+// json.Marshal, projects arbitrary input values according to mask m (only
+// positive paths are emitted; negative or absent paths are omitted). Options
+// such as WithRedaction adjust how excluded members are handled. Only the
+// value at the top level of the encoder is projected; values encoded below
+// an open object or array are left alone.
+func MarshalWithMask(m *Mask, opts ...Option) *json.Marshalers {
+	mm := &maskMarshaler{m: m, o: newOptions(opts), root: true}
+	return json.MarshalToFunc(mm.marshal)
+}
+
+// scratchEncoders holds the encoders source values are marshaled into before
+// being projected, mapped to the *maskMarshaler that created them. Values are
+// marshaled with the caller's options so that type-targeted marshalers (see
+// MaskSet) compose; the registry lets every mask marshaler recognise, and
+// skip, the value it is itself in the middle of encoding. That value is always
+// at the top level of its encoder, so only top-level values are looked up.
+var scratchEncoders sync.Map // map[*jsontext.Encoder]*maskMarshaler
+
+// maskMarshaler applies a mask to the values it is invoked for.
+type maskMarshaler struct {
+	m *Mask
+	o *options
+	// root marks MarshalWithMask, which only projects the outermost value:
+	// nested values are encoded inside its own projection.
+	root bool
+}
+
+func (mm *maskMarshaler) marshal(enc *jsontext.Encoder, v any) error {
+	if mm.m == nil {
+		return json.SkipFunc
+	}
+	if enc.StackDepth() > 0 {
+		// MarshalWithMask only projects the outermost value: anything deeper
+		// is being encoded inside its projection, either into a scratch
+		// encoder or by a MaskedMarshaler.
+		if mm.root {
+			return json.SkipFunc
 		}
-		// finalize mode for this mask (negative-only => Negative)
-		if !f.hasPos && f.hasNeg {
-			mask.Mode = Negative
-		} else {
-			mask.Mode = Positive
+	} else if owner, ok := scratchEncoders.Load(enc); ok && (mm.root || owner == mm) {
+		return json.SkipFunc
+	}
+	return mm.apply(enc, v)
+}
+
+// apply writes v projected by mm.m to enc.
+func (mm *maskMarshaler) apply(enc *jsontext.Encoder, v any) error {
+	if mm.o.plain() {
+		if masked, ok := asMaskedMarshaler(enc, v); ok {
+			return masked.MarshalMasked(enc, mm.m)
+		}
+	}
+	return mm.project(enc, v)
+}
+
+// project marshals v and writes its projection by mm.m to enc.
+func (mm *maskMarshaler) project(enc *jsontext.Encoder, v any) error {
+	var buf bytes.Buffer
+	// The source is always compact: formatting is the job of enc, and Stats
+	// count the bytes of skipped values without whitespace.
+	src := jsontext.NewEncoder(&buf, enc.Options(), jsontext.Multiline(false), jsontext.SpaceAfterColon(false), jsontext.SpaceAfterComma(false))
+	scratchEncoders.Store(src, mm)
+	err := json.MarshalEncode(src, v)
+	scratchEncoders.Delete(src)
+	if err != nil {
+		return fmt.Errorf("marshal mask source: %w", err)
+	}
+	dec := jsontext.NewDecoder(&buf)
+	o := mm.o
+	if !o.strict {
+		p := newProjector(dec, enc, o)
+		if err := p.copyMasked(mm.m); err != nil {
+			return err
 		}
+		p.finish(mm.m)
 		return nil
-	})
+	}
+
+	// Strict mode: only write once every mask path is known to match.
+	var out bytes.Buffer
+	p := newProjector(dec, jsontext.NewEncoder(&out), o)
+	if err := p.copyMasked(mm.m); err != nil {
+		return err
+	}
+	if unmatched := p.finish(mm.m); len(unmatched) > 0 {
+		return &UnmatchedPathsError{Paths: unmatched}
+	}
+	return enc.WriteValue(out.Bytes())
 }
 
-// WithMask returns a json.Marshalers helper that, when supplied to
-// json.Marshal, projects arbitrary input values according to mask m (only
-// positive paths are emitted; negative or absent paths are omitted).
-func MarshalWithMask(m *Mask) *json.Marshalers {
-	return json.MarshalToFunc(func(enc *jsontext.Encoder, v any) error {
-		if m == nil {
+// scratchDecoders holds the decoders UnmarshalWithMask decodes projected input
+// from. They carry the caller's options, and with them the mask unmarshaler
+// itself, which must leave their values alone.
+var scratchDecoders sync.Map // map[*jsontext.Decoder]struct{}
+
+// UnmarshalWithMask returns a json.Unmarshalers helper that, when supplied to
+// json.Unmarshal, only lets input fields covered by mask m through to the
+// destination value. It is the write-side mirror of MarshalWithMask and uses
+// the same Positive/Negative/override semantics: members MarshalWithMask would
+// drop are silently discarded before decoding, or rejected with a
+// *DisallowedFieldsError when WithRejectDisallowed is given. The retained
+// members are decoded with the options of the calling decoder. Like
+// MarshalWithMask it only applies to the outermost value.
+func UnmarshalWithMask(m *Mask, opts ...Option) *json.Unmarshalers {
+	o := newOptions(opts)
+	return json.UnmarshalFromFunc(func(dec *jsontext.Decoder, v any) error {
+		if m == nil || dec.StackDepth() > 0 {
+			return json.SkipFunc
+		}
+		if _, ok := scratchDecoders.Load(dec); ok {
 			return json.SkipFunc
 		}
 
 		var buf bytes.Buffer
-		if err := json.MarshalWrite(&buf, v); err != nil {
-			return fmt.Errorf("marshal mask source: %w", err)
-		}
-		dec := jsontext.NewDecoder(&buf)
-
-		// copyRaw copies the next value from dec to enc verbatim.
-		var copyRaw func() error
-		copyRaw = func() error {
-			switch dec.PeekKind() {
-			case '{':
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read '{': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.BeginObject); err != nil {
-					return fmt.Errorf("write '{': %w", err)
-				}
-				for dec.PeekKind() != '}' {
-					var key string
-					if err := json.UnmarshalDecode(dec, &key); err != nil {
-						return fmt.Errorf("read key (raw copy): %w", err)
-					}
-					if err := enc.WriteToken(jsontext.String(key)); err != nil {
-						return fmt.Errorf("write key (raw copy): %w", err)
-					}
-					if err := copyRaw(); err != nil {
-						return err
-					}
-				}
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read '}': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.EndObject); err != nil {
-					return fmt.Errorf("write '}': %w", err)
-				}
-			case '[':
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read '[': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.BeginArray); err != nil {
-					return fmt.Errorf("write '[': %w", err)
-				}
-				for dec.PeekKind() != ']' {
-					if err := copyRaw(); err != nil {
-						return err
-					}
-				}
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read ']': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.EndArray); err != nil {
-					return fmt.Errorf("write ']': %w", err)
-				}
-			default:
-				tok, err := dec.ReadToken()
-				if err != nil {
-					return fmt.Errorf("read scalar: %w", err)
-				}
-				if err := enc.WriteToken(tok); err != nil {
-					return fmt.Errorf("write scalar: %w", err)
+		// Only the write-side options apply when decoding.
+		p := newProjector(dec, jsontext.NewEncoder(&buf), &options{rejectDisallowed: o.rejectDisallowed})
+		if err := p.copyMasked(m); err != nil {
+			return err
+		}
+		if len(p.dropped) > 0 {
+			return &DisallowedFieldsError{Paths: p.dropped}
+		}
+		src := jsontext.NewDecoder(&buf, dec.Options())
+		scratchDecoders.Store(src, struct{}{})
+		err := json.UnmarshalDecode(src, v)
+		scratchDecoders.Delete(src)
+		if err != nil {
+			return fmt.Errorf("unmarshal masked input: %w", err)
+		}
+		return nil
+	})
+}
+
+// DisallowedFieldsError is returned by UnmarshalWithMask, when configured with
+// WithRejectDisallowed, if the input sets fields outside the writable mask.
+// Paths are dotted member paths in input order (array indices are omitted).
+type DisallowedFieldsError struct {
+	Paths []string
+}
+
+func (e *DisallowedFieldsError) Error() string {
+	return fmt.Sprintf("disallowed fields: %s", strings.Join(e.Paths, ", "))
+}
+
+// projector streams a single JSON value from dec to enc, applying a mask on
+// the way. It is shared by MarshalWithMask and UnmarshalWithMask.
+type projector struct {
+	dec *jsontext.Decoder
+	enc *jsontext.Encoder
+	o   *options
+
+	// trackPath enables maintenance of path, the keys leading from the root
+	// value to the member currently being copied.
+	trackPath bool
+	path      []string
+	// dropped collects the (deduplicated) paths of keys removed by the mask
+	// when rejecting disallowed fields.
+	dropped []string
+	// matched records the mask nodes that matched a member, when non-nil.
+	matched map[*Node]bool
+	// stats accumulates the projection counters.
+	stats Stats
+}
+
+func newProjector(dec *jsontext.Decoder, enc *jsontext.Encoder, o *options) *projector {
+	p := &projector{dec: dec, enc: enc, o: o, trackPath: o.needsPath()}
+	if o.tracksMatches() {
+		p.matched = make(map[*Node]bool)
+	}
+	return p
+}
+
+// finish publishes the results of a completed projection of mask m to the
+// Report and Stats configured in the options, and returns the unmatched mask
+// paths when they are tracked.
+func (p *projector) finish(m *Mask) []string {
+	if p.o.stats != nil {
+		*p.o.stats = p.stats
+	}
+	if p.matched == nil {
+		return nil
+	}
+	unmatched := unmatchedPaths(m, p.matched)
+	if p.o.report != nil {
+		p.o.report.Unmatched = unmatched
+	}
+	return unmatched
+}
+
+// pathString returns the dotted form of the current path.
+func (p *projector) pathString() string {
+	return strings.Join(p.path, ".")
+}
+
+// skip handles the value of the member key excluded by the mask: it is either
+// discarded, or replaced by a placeholder when redaction applies. The member
+// key has not been written yet.
+func (p *projector) skip(key string) error {
+	var path string
+	if p.trackPath {
+		p.path = append(p.path, key)
+		path = p.pathString()
+		p.path = p.path[:len(p.path)-1]
+		if p.o.rejectDisallowed && !slices.Contains(p.dropped, path) {
+			p.dropped = append(p.dropped, path)
+		}
+	}
+	if p.o.observer != nil {
+		p.o.observer(path, false)
+	}
+	if ph, ok := p.o.placeholder(path); ok {
+		if err := p.enc.WriteToken(jsontext.String(key)); err != nil {
+			return fmt.Errorf("write key %q: %w", key, err)
+		}
+		if err := ph.write(p.enc, p.dec.PeekKind()); err != nil {
+			return fmt.Errorf("write placeholder for %q: %w", key, err)
+		}
+	}
+	val, err := p.dec.ReadValue()
+	if err != nil {
+		return fmt.Errorf("skip masked value %q: %w", key, err)
+	}
+	p.stats.KeysSkipped++
+	p.stats.BytesSkipped += int64(len(val))
+	return nil
+}
+
+// copyMember writes the member key and copies its value under sub (nil copies
+// it verbatim).
+func (p *projector) copyMember(key string, sub *Mask) error {
+	if err := p.enc.WriteToken(jsontext.String(key)); err != nil {
+		return fmt.Errorf("write key %q: %w", key, err)
+	}
+	return p.copyValue(key, sub)
+}
+
+// copyValue copies the value of the member key under sub, applying the
+// transformer registered for the member's path.
+func (p *projector) copyValue(key string, sub *Mask) error {
+	p.stats.KeysKept++
+	if !p.trackPath {
+		return p.copyMasked(sub)
+	}
+	p.path = append(p.path, key)
+	if p.o.observer != nil {
+		p.o.observer(p.pathString(), true)
+	}
+	var err error
+	if fn, ok := p.o.transforms[p.pathString()]; ok {
+		err = p.transform(fn, sub)
+	} else {
+		err = p.copyMasked(sub)
+	}
+	p.path = p.path[:len(p.path)-1]
+	return err
+}
+
+// capture runs fn with the output redirected to a scratch buffer and returns
+// the single JSON value it wrote.
+func (p *projector) capture(fn func() error) (jsontext.Value, error) {
+	var buf bytes.Buffer
+	enc := p.enc
+	p.enc = jsontext.NewEncoder(&buf)
+	err := fn()
+	p.enc = enc
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// transform projects the next value under sub into a scratch buffer, passes
+// it through fn and writes the result.
+func (p *projector) transform(fn Transformer, sub *Mask) error {
+	val, err := p.capture(func() error { return p.copyMasked(sub) })
+	if err != nil {
+		return err
+	}
+	out, err := fn(val)
+	if err != nil {
+		return fmt.Errorf("transform %q: %w", p.pathString(), err)
+	}
+	if err := p.enc.WriteValue(out); err != nil {
+		return fmt.Errorf("write transformed %q: %w", p.pathString(), err)
+	}
+	return nil
+}
+
+// copyRaw copies the next value from dec to enc verbatim.
+func (p *projector) copyRaw() error {
+	dec, enc := p.dec, p.enc
+	switch dec.PeekKind() {
+	case '{':
+		if _, err := dec.ReadToken(); err != nil {
+			return fmt.Errorf("read '{': %w", err)
+		}
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return fmt.Errorf("write '{': %w", err)
+		}
+		for dec.PeekKind() != '}' {
+			var key string
+			if err := json.UnmarshalDecode(dec, &key); err != nil {
+				return fmt.Errorf("read key (raw copy): %w", err)
+			}
+			if p.trackPath {
+				// Members below a verbatim subtree may still be transformed
+				// or observed.
+				if err := p.copyMember(key, nil); err != nil {
+					return err
 				}
+				continue
+			}
+			if err := enc.WriteToken(jsontext.String(key)); err != nil {
+				return fmt.Errorf("write key (raw copy): %w", err)
+			}
+			p.stats.KeysKept++
+			if err := p.copyRaw(); err != nil {
+				return err
 			}
-			return nil
 		}
+		if _, err := dec.ReadToken(); err != nil {
+			return fmt.Errorf("read '}': %w", err)
+		}
+		if err := enc.WriteToken(jsontext.EndObject); err != nil {
+			return fmt.Errorf("write '}': %w", err)
+		}
+	case '[':
+		if _, err := dec.ReadToken(); err != nil {
+			return fmt.Errorf("read '[': %w", err)
+		}
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return fmt.Errorf("write '[': %w", err)
+		}
+		for dec.PeekKind() != ']' {
+			if err := p.copyRaw(); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.ReadToken(); err != nil {
+			return fmt.Errorf("read ']': %w", err)
+		}
+		if err := enc.WriteToken(jsontext.EndArray); err != nil {
+			return fmt.Errorf("write ']': %w", err)
+		}
+	default:
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return fmt.Errorf("read scalar: %w", err)
+		}
+		if err := enc.WriteToken(tok); err != nil {
+			return fmt.Errorf("write scalar: %w", err)
+		}
+	}
+	return nil
+}
 
-		// copyMasked copies the next value applying the provided mask.
-		var copyMasked func(mask *Mask, ancestorExcluded bool) error
-		copyMasked = func(mask *Mask, ancestorExcluded bool) error {
-			// No mask means copy everything.
-			if mask == nil {
-				return copyRaw()
+// copyMasked copies the next value applying the provided mask.
+func (p *projector) copyMasked(mask *Mask) error {
+	// No mask means copy everything.
+	if mask == nil {
+		return p.copyRaw()
+	}
+	dec, enc := p.dec, p.enc
+	switch dec.PeekKind() {
+	case '{':
+		return p.copyObject(mask)
+	case '[':
+		// Apply same mask to each element.
+		if _, err := dec.ReadToken(); err != nil {
+			return fmt.Errorf("read '[': %w", err)
+		}
+		if err := enc.WriteToken(jsontext.BeginArray); err != nil {
+			return fmt.Errorf("write '[': %w", err)
+		}
+		for dec.PeekKind() != ']' {
+			if err := p.copyMasked(mask); err != nil {
+				return err
 			}
-			// Choose algorithm based on root mode and context.
-			switch dec.PeekKind() {
-			case '{':
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read '{': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.BeginObject); err != nil {
-					return fmt.Errorf("write '{': %w", err)
-				}
-				for dec.PeekKind() != '}' {
-					var key string
-					if err := json.UnmarshalDecode(dec, &key); err != nil {
-						return fmt.Errorf("read key: %w", err)
-					}
-					node, ok := mask.Fields[key]
-					if mask.Mode == Positive || ancestorExcluded {
-						// Whitelist semantics (or inside an excluded-override
-						// subtree): Only explicitly included paths are emitted.
-						//
-						// Override support for pattern: -parent:(child,...) If
-						// a negative node has children we treat it as an
-						// exclusion of the whole subtree with selective
-						// re-includes of its positive descendants, even when
-						// the overall root mode was detected as Positive (this
-						// can happen today because root mode auto-detection
-						// counts nested positives). This enables the documented
-						// expression `-z:(x)` to yield `{"z":{"x":..}}`.
-						if !ok || node.Op == Negative && (node.Children == nil || len(node.Children.Fields) == 0) {
-							// Simple negative (no override children) or absent
-							// field: skip.
-							if err := dec.SkipValue(); err != nil {
-								return fmt.Errorf("skip masked value %q: %w", key, err)
-							}
-							continue
-						}
-						if ok && node.Op == Negative && node.Children != nil && len(node.Children.Fields) > 0 {
-							// Negative-with-children override: emit key and
-							// descend with whitelist semantics limited to the
-							// provided children.
-							if err := enc.WriteToken(jsontext.String(key)); err != nil {
-								return fmt.Errorf("write key %q: %w", key, err)
-							}
-							if err := copyMasked(&Mask{Mode: Positive, Fields: node.Children.Fields}, true); err != nil {
-								return err
-							}
-							continue
-						}
-						// Standard positive include path.
-						if err := enc.WriteToken(jsontext.String(key)); err != nil {
-							return fmt.Errorf("write key %q: %w", key, err)
-						}
-						if node.Children != nil && len(node.Children.Fields) > 0 {
-							if err := copyMasked(node.Children, false); err != nil {
-								return err
-							}
-						} else if err := copyRaw(); err != nil {
-							return err
-						}
-					} else { // ModeExclude outside override
-						// Blacklist semantics: skip only explicit excludes;
-						// includes can narrow subtrees.
-						if ok && node.Op == Negative {
-							if node.Children == nil || len(node.Children.Fields) == 0 {
-								if err := dec.SkipValue(); err != nil {
-									return fmt.Errorf("skip excluded %q: %w", key, err)
-								}
-								continue
-							}
-							// Excluded subtree with potential re-includes: we
-							// need to read object and only output included
-							// children. Start object in output regardless
-							// (unless everything filtered -> produce empty
-							// object). Write key then filter children with
-							// ancestorExcluded=true.
-							if err := enc.WriteToken(jsontext.String(key)); err != nil {
-								return err
-							}
-							// Descend marking ancestorExcluded=true so only
-							// explicit includes survive.
-							if err := copyMasked(&Mask{Mode: Positive, Fields: node.Children.Fields}, true); err != nil {
-								return err
-							}
-						} else {
-							// Included by default or explicitly included.
-							if err := enc.WriteToken(jsontext.String(key)); err != nil {
-								return err
-							}
-							if ok && node.Children != nil && len(node.Children.Fields) > 0 {
-								if err := copyMasked(node.Children, false); err != nil {
-									return err
-								}
-							} else if err := copyRaw(); err != nil {
-								return err
-							}
-						}
-					}
-				}
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read '}': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.EndObject); err != nil {
-					return fmt.Errorf("write '}': %w", err)
-				}
-			case '[':
-				// Apply same mask to each element.
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read '[': %w", err)
-				}
-				if err := enc.WriteToken(jsontext.BeginArray); err != nil {
-					return fmt.Errorf("write '[': %w", err)
-				}
-				for dec.PeekKind() != ']' {
-					if err := copyMasked(mask, ancestorExcluded); err != nil {
-						return err
-					}
-				}
-				if _, err := dec.ReadToken(); err != nil {
-					return fmt.Errorf("read ']': %w", err)
+		}
+		if _, err := dec.ReadToken(); err != nil {
+			return fmt.Errorf("read ']': %w", err)
+		}
+		if err := enc.WriteToken(jsontext.EndArray); err != nil {
+			return fmt.Errorf("write ']': %w", err)
+		}
+	default:
+		// Scalar at a masked location: copy if we reached here (meaning
+		// parent allowed it).
+		return p.copyRaw()
+	}
+	return nil
+}
+
+// copyObject copies the next value, an object, applying mask to its members.
+//
+// With WithMaskOrder, members of Positive-mode objects are emitted in mask
+// order: a member is written as soon as every member listed before it has been
+// written, otherwise its projected value is buffered until the end of the
+// object. Objects whose source order already matches the mask are streamed
+// without buffering.
+func (p *projector) copyObject(mask *Mask) error {
+	dec, enc := p.dec, p.enc
+	if _, err := dec.ReadToken(); err != nil {
+		return fmt.Errorf("read '{': %w", err)
+	}
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return fmt.Errorf("write '{': %w", err)
+	}
+	var seen map[string]bool // mask keys present in the source
+	if p.o.explicitNulls {
+		seen = make(map[string]bool, len(mask.Fields))
+	}
+	var (
+		order   []string       // keepable mask keys in output order
+		index   map[string]int // position of each key in order
+		pending map[int]jsontext.Value
+		next    int // position of the first member not yet written
+	)
+	if p.o.maskOrder && mask.Mode == Positive {
+		order = keepableKeys(mask)
+		index = make(map[string]int, len(order))
+		for i, k := range order {
+			index[k] = i
+		}
+	}
+	for dec.PeekKind() != '}' {
+		var key string
+		if err := json.UnmarshalDecode(dec, &key); err != nil {
+			return fmt.Errorf("read key: %w", err)
+		}
+		// Whitelist (Positive) or blacklist (Negative) semantics, including
+		// the -parent:(child,...) override which emits the key but only its
+		// positive descendants. This enables the documented expression
+		// `-z:(x)` to yield `{"z":{"x":..}}`.
+		keep, sub := mask.Resolve(key)
+		if p.matched != nil {
+			if node, ok := mask.lookup(key); ok {
+				p.matched[node] = true
+			}
+		}
+		if seen != nil {
+			seen[key] = true
+		}
+		if !keep {
+			if err := p.skip(key); err != nil {
+				return err
+			}
+			continue
+		}
+		i, ordered := index[key]
+		if ordered && i != next {
+			val, err := p.capture(func() error { return p.copyValue(key, sub) })
+			if err != nil {
+				return err
+			}
+			if pending == nil {
+				pending = make(map[int]jsontext.Value)
+			}
+			pending[i] = val
+			continue
+		}
+		if err := p.copyMember(key, sub); err != nil {
+			return err
+		}
+		if !ordered {
+			continue
+		}
+		// Flush buffered members that were waiting for this one.
+		for next++; next < len(order); next++ {
+			val, ok := pending[next]
+			if !ok {
+				break
+			}
+			if err := writeMember(enc, order[next], val); err != nil {
+				return err
+			}
+			delete(pending, next)
+		}
+	}
+	if _, err := dec.ReadToken(); err != nil {
+		return fmt.Errorf("read '}': %w", err)
+	}
+	if order != nil {
+		for ; next < len(order); next++ {
+			key := order[next]
+			if val, ok := pending[next]; ok {
+				if err := writeMember(enc, key, val); err != nil {
+					return err
 				}
-				if err := enc.WriteToken(jsontext.EndArray); err != nil {
-					return fmt.Errorf("write ']': %w", err)
+				continue
+			}
+			if seen != nil && !seen[key] {
+				if err := writeAbsent(enc, key, mask.Fields[key]); err != nil {
+					return err
 				}
-			default:
-				// Scalar at a masked location: copy if we reached here (meaning
-				// parent allowed it).
-				return copyRaw()
 			}
-			return nil
 		}
+	} else if seen != nil {
+		for _, key := range orderedKeys(mask) {
+			if seen[key] {
+				continue
+			}
+			if err := writeAbsent(enc, key, mask.Fields[key]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := enc.WriteToken(jsontext.EndObject); err != nil {
+		return fmt.Errorf("write '}': %w", err)
+	}
+	return nil
+}
 
-		return copyMasked(m, false)
-	})
+// writeMember writes the member key with the already projected value val.
+func writeMember(enc *jsontext.Encoder, key string, val jsontext.Value) error {
+	if err := enc.WriteToken(jsontext.String(key)); err != nil {
+		return fmt.Errorf("write key %q: %w", key, err)
+	}
+	if err := enc.WriteValue(val); err != nil {
+		return fmt.Errorf("write value %q: %w", key, err)
+	}
+	return nil
+}
+
+// keepableKeys returns, in mask order, the keys of m whose members can be kept
+// (positive nodes and override subtrees). The Wildcard entry names no member of
+// its own and is left out.
+func keepableKeys(m *Mask) []string {
+	keys := orderedKeys(m)
+	res := keys[:0]
+	for _, k := range keys {
+		if n := m.Fields[k]; k != Wildcard && (n.Op == Positive || n.hasChildren()) {
+			res = append(res, k)
+		}
+	}
+	return res
 }
@@ -3,10 +3,10 @@ package kino_test
 import (
 	"testing"
 
-	"github.com/go-json-experiment/json"
 	"github.com/stretchr/testify/require"
 
 	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
 )
 
 type sample struct {
@@ -128,4 +128,149 @@ func TestMarshalWithMask(t *testing.T) {
 		require.NotNil(t, child)
 		require.Equal(t, kino.Negative, child.Mode)
 	})
+
+	t.Run("unmarshalers negative override round trip projected", func(t *testing.T) {
+		m, err := kino.ParseMask("a,-z:(x)")
+		require.NoError(t, err)
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		var m2 kino.Mask
+		require.NoError(t, json.Unmarshal(data, &m2, json.WithUnmarshalers(kino.MaskUnmarshalers())))
+		require.Equal(t, m.String(), m2.String())
+
+		out, err := json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithMask(&m2)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va","z":{"x":10}}`, string(out))
+	})
+
+	t.Run("unmarshalers dash key with leaf stays literal", func(t *testing.T) {
+		var m kino.Mask
+		require.NoError(t, json.Unmarshal([]byte(`{"-a":true}`), &m, json.WithUnmarshalers(kino.MaskUnmarshalers())))
+		require.Equal(t, kino.Positive, m.Fields["-a"].Op)
+	})
+}
+
+func TestUnmarshalWithMask(t *testing.T) {
+	const patch = `{"a":"na","b":"nb","c":{"d":5,"e":6},"z":{"x":7,"y":8}}`
+
+	t.Run("nil mask is skip", func(t *testing.T) {
+		var s sample
+		require.NoError(t, json.Unmarshal([]byte(patch), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(nil))))
+		require.Equal(t, "nb", s.B)
+	})
+
+	t.Run("a,c:(d),-z:(x) disallowed fields dropped", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(d),-z:(x)")
+		require.NoError(t, err)
+		s := buildSample()
+		require.NoError(t, json.Unmarshal([]byte(patch), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(m))))
+		want := buildSample()
+		want.A = "na"
+		want.C.D = 5
+		want.Z.X = 7
+		require.Equal(t, want, s)
+	})
+
+	t.Run("-b,-c:(-e) negative mask drops excluded", func(t *testing.T) {
+		m, err := kino.ParseMask("-b,-c:(-e)")
+		require.NoError(t, err)
+		s := buildSample()
+		require.NoError(t, json.Unmarshal([]byte(patch), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(m))))
+		require.Equal(t, "na", s.A)
+		require.Equal(t, "vb", s.B)
+		require.Equal(t, 1, s.C.D)
+		require.Equal(t, 2, s.C.E)
+		require.Equal(t, 8, s.Z.Y)
+	})
+
+	t.Run("-b,-c negative mask drops excluded subtree", func(t *testing.T) {
+		m, err := kino.ParseMask("-b,-c")
+		require.NoError(t, err)
+		s := buildSample()
+		require.NoError(t, json.Unmarshal([]byte(patch), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(m))))
+		require.Equal(t, "na", s.A)
+		require.Equal(t, "vb", s.B)
+		require.Equal(t, 1, s.C.D)
+		require.Equal(t, 8, s.Z.Y)
+	})
+
+	t.Run("caller options apply to retained members", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c")
+		require.NoError(t, err)
+		var s sample
+		require.NoError(t, json.Unmarshal([]byte(`{"A":"x","C":{"D":3},"b":"y"}`), &s,
+			json.WithUnmarshalers(kino.UnmarshalWithMask(m)), json.MatchCaseInsensitiveNames(true)))
+		require.Zero(t, s.A) // mask keys stay case-sensitive
+		s = sample{}
+		require.NoError(t, json.Unmarshal([]byte(`{"a":"x","c":{"D":3}}`), &s,
+			json.WithUnmarshalers(kino.UnmarshalWithMask(m)), json.MatchCaseInsensitiveNames(true)))
+		require.Equal(t, "x", s.A)
+		require.Equal(t, 3, s.C.D)
+
+		err = json.Unmarshal([]byte(`{"a":"x","c":{"unknown":1}}`), &s,
+			json.WithUnmarshalers(kino.UnmarshalWithMask(m)), json.RejectUnknownMembers(true))
+		require.Error(t, err)
+	})
+
+	t.Run("reject disallowed typed error", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(d),-z:(x)")
+		require.NoError(t, err)
+		var s sample
+		err = json.Unmarshal([]byte(patch), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(m, kino.WithRejectDisallowed())))
+		var derr *kino.DisallowedFieldsError
+		require.ErrorAs(t, err, &derr)
+		require.Equal(t, []string{"b", "c.e", "z.y"}, derr.Paths)
+		require.Zero(t, s)
+	})
+
+	t.Run("reject disallowed arrays deduplicated", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		var s []sample
+		err = json.Unmarshal([]byte(`[{"a":"1","b":"2"},{"b":"3"}]`), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(m, kino.WithRejectDisallowed())))
+		var derr *kino.DisallowedFieldsError
+		require.ErrorAs(t, err, &derr)
+		require.Equal(t, []string{"b"}, derr.Paths)
+	})
+
+	t.Run("reject disallowed passes when all allowed", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(d)")
+		require.NoError(t, err)
+		var s sample
+		require.NoError(t, json.Unmarshal([]byte(`{"a":"x","c":{"d":3}}`), &s, json.WithUnmarshalers(kino.UnmarshalWithMask(m, kino.WithRejectDisallowed()))))
+		require.Equal(t, "x", s.A)
+		require.Equal(t, 3, s.C.D)
+	})
+}
+
+func BenchmarkMarshalWithMask(b *testing.B) {
+	m, err := kino.ParseMask("a,c:(d),-z:(x)")
+	require.NoError(b, err)
+	docs := make([]sample, 100)
+	for i := range docs {
+		docs[i] = buildSample()
+	}
+
+	b.Run("mask", func(b *testing.B) {
+		opts := json.WithMarshalers(kino.MarshalWithMask(m))
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := json.Marshal(docs, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("mask with type-targeted mask", func(b *testing.B) {
+		opts := json.WithMarshalers(json.JoinMarshalers(
+			kino.MarshalWithMaskFor[sample](m),
+			kino.MarshalWithMask(m),
+		))
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := json.Marshal(docs, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
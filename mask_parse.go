@@ -40,6 +40,7 @@ func ParseMask(s string) (*Mask, error) {
 			return fmt.Errorf("duplicate field '%s' at index %d", name, idx)
 		}
 		cur.m.Fields[name] = &Node{Op: op}
+		cur.m.Order = append(cur.m.Order, name)
 		switch op {
 		case Positive:
 			cur.hasPos = true
@@ -58,6 +59,7 @@ func ParseMask(s string) (*Mask, error) {
 		}
 		child := &Mask{Mode: Positive, Fields: make(map[string]*Node)}
 		cur.m.Fields[name] = &Node{Op: op, Children: child}
+		cur.m.Order = append(cur.m.Order, name)
 		// Update counters for parent (based on the node op itself).
 		switch op {
 		case Positive:
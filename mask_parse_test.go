@@ -63,4 +63,11 @@ func TestParseMask(t *testing.T) {
 		require.NotNil(t, zNode.Children)
 		require.Equal(t, kino.Negative, zNode.Children.Fields["x"].Op)
 	})
+
+	t.Run("c:(e,d),a,-b order recorded", func(t *testing.T) {
+		m, err := kino.ParseMask("c:(e,d),a,-b")
+		require.NoError(t, err)
+		require.Equal(t, []string{"c", "a", "b"}, m.Order)
+		require.Equal(t, []string{"e", "d"}, m.Fields["c"].Children.Order)
+	})
 }
@@ -61,7 +61,7 @@ func TestMask_Overlay(t *testing.T) {
 		require.Equal(t, kino.Positive, got.Fields["a"].Op)
 	})
 
-	t.Run("overlay child with no children ignored", func(t *testing.T) {
+	t.Run("negative leaf keeps out overlay children", func(t *testing.T) {
 		base := maskPositive(map[string]*kino.Node{
 			"a": {Op: kino.Negative /* no children */},
 		})
@@ -72,9 +72,8 @@ func TestMask_Overlay(t *testing.T) {
 		})
 		got := base.Overlay(overlay)
 		require.Equal(t, kino.Negative, got.Fields["a"].Op)
-		require.NotNil(t, got.Fields["a"].Children)
-		require.ElementsMatch(t, []string{"x"}, keys(got.Fields["a"].Children))
-		require.Equal(t, kino.Positive, got.Fields["a"].Children.Fields["x"].Op)
+		// Children would turn the exclusion into an override keeping a.x.
+		require.Nil(t, got.Fields["a"].Children)
 	})
 
 	t.Run("children merged recursively", func(t *testing.T) {
@@ -134,6 +133,15 @@ func TestMask_Overlay(t *testing.T) {
 		require.Nil(t, got.Fields["a"].Children)
 	})
 
+	t.Run("order base first then overlay additions", func(t *testing.T) {
+		base, err := kino.ParseMask("c,a")
+		require.NoError(t, err)
+		overlay, err := kino.ParseMask("z,a,b")
+		require.NoError(t, err)
+		got := base.Overlay(overlay)
+		require.Equal(t, []string{"c", "a", "z", "b"}, got.Order)
+	})
+
 	t.Run("deep immutability modifications don't leak", func(t *testing.T) {
 		base := maskPositive(map[string]*kino.Node{
 			"a": {Op: kino.Positive, Children: maskPositive(map[string]*kino.Node{"x": {Op: kino.Positive}})},
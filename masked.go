@@ -0,0 +1,65 @@
+package kino
+
+import (
+	"reflect"
+
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// MaskedMarshaler is implemented by types that write their own projection,
+// typically through methods generated by cmd/kinogen -marshal. MarshalWithMask
+// uses it instead of the generic reflective projection when no Option is
+// given; implementations must produce the output the generic path would.
+// Encoders setting options that change how strings, numbers, booleans or nil
+// values are written, such as json.StringifyNumbers, always use the generic
+// path: generated methods write those values directly.
+type MaskedMarshaler interface {
+	MarshalMasked(enc *jsontext.Encoder, m *Mask) error
+}
+
+// MarshalMasked writes v to enc projected by m. Values implementing
+// MaskedMarshaler project themselves unless enc sets such options; other
+// values are marshaled with the options of enc and projected generically. A
+// nil mask writes v unchanged.
+func MarshalMasked(enc *jsontext.Encoder, v any, m *Mask) error {
+	if masked, ok := asMaskedMarshaler(enc, v); ok {
+		return masked.MarshalMasked(enc, m)
+	}
+	if m == nil {
+		return json.MarshalEncode(enc, v)
+	}
+	return (&maskMarshaler{m: m, o: &options{}, root: true}).project(enc, v)
+}
+
+// asMaskedMarshaler returns v, or the value v points to, as a MaskedMarshaler,
+// unless the options of enc rule MaskedMarshaler implementations out.
+func asMaskedMarshaler(enc *jsontext.Encoder, v any) (MaskedMarshaler, bool) {
+	if formatsValues(enc.Options()) {
+		return nil, false
+	}
+	if masked, ok := v.(MaskedMarshaler); ok {
+		return masked, true
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Pointer {
+		masked, ok := rv.Elem().Interface().(MaskedMarshaler)
+		return masked, ok
+	}
+	return nil, false
+}
+
+// formatsValues reports whether opts change how values that MaskedMarshaler
+// implementations may write directly are encoded.
+func formatsValues(opts json.Options) bool {
+	for _, set := range []func(bool) json.Options{
+		json.StringifyNumbers,
+		json.FormatNilSliceAsNull,
+		json.FormatNilMapAsNull,
+		json.OmitZeroStructFields,
+	} {
+		if on, _ := json.GetOption(opts, set); on {
+			return true
+		}
+	}
+	return false
+}
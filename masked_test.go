@@ -0,0 +1,82 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// maskedItem projects itself, writing only the kept members of a fixed set and
+// counting the calls.
+type maskedItem struct {
+	calls *int
+	Name  string         `json:"name"`
+	Inner map[string]int `json:"inner"`
+}
+
+func (it *maskedItem) MarshalMasked(enc *jsontext.Encoder, m *kino.Mask) error {
+	*it.calls++
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if keep, _ := m.Resolve("name"); keep {
+		if err := enc.WriteToken(jsontext.String("name")); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(it.Name)); err != nil {
+			return err
+		}
+	}
+	if keep, sub := m.Resolve("inner"); keep {
+		if err := enc.WriteToken(jsontext.String("inner")); err != nil {
+			return err
+		}
+		if err := kino.MarshalMasked(enc, &it.Inner, sub); err != nil {
+			return err
+		}
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
+
+func TestMaskedMarshaler(t *testing.T) {
+	m, err := kino.ParseMask("inner:(a)")
+	require.NoError(t, err)
+
+	t.Run("used without options", func(t *testing.T) {
+		calls := 0
+		it := maskedItem{calls: &calls, Name: "n", Inner: map[string]int{"a": 1, "b": 2}}
+		for _, v := range []any{it, &it} {
+			out, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m)))
+			require.NoError(t, err)
+			require.JSONEq(t, `{"inner":{"a":1}}`, string(out))
+		}
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("nested values not projected again", func(t *testing.T) {
+		calls := 0
+		it := maskedItem{calls: &calls, Inner: map[string]int{"inner": 1}}
+		out, err := json.Marshal(it, json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"inner":{}}`, string(out))
+		full, err := kino.ParseMask("inner")
+		require.NoError(t, err)
+		out, err = json.Marshal(it, json.WithMarshalers(kino.MarshalWithMask(full)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"inner":{"inner":1}}`, string(out))
+	})
+
+	t.Run("options use generic path", func(t *testing.T) {
+		calls := 0
+		it := maskedItem{calls: &calls, Name: "n", Inner: map[string]int{"a": 1}}
+		var r kino.Report
+		out, err := json.Marshal(it, json.WithMarshalers(kino.MarshalWithMask(m, kino.WithReport(&r))))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"inner":{"a":1}}`, string(out))
+		require.Zero(t, calls)
+	})
+}
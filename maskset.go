@@ -0,0 +1,45 @@
+package kino
+
+import (
+	"reflect"
+
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// MarshalWithMaskFor returns a json.Marshalers helper that projects every value
+// of type T with mask m, wherever it appears in the marshaled tree. Unlike
+// MarshalWithMask, which only projects the outermost value, it composes with
+// other marshalers through json.JoinMarshalers: combined with MarshalWithMask,
+// nested T values get their own mask even when the outer mask includes them
+// wholesale (the outer mask then applies to the already projected value).
+func MarshalWithMaskFor[T any](m *Mask, opts ...Option) *json.Marshalers {
+	mm := &maskMarshaler{m: m, o: newOptions(opts)}
+	return json.MarshalToFunc(func(enc *jsontext.Encoder, v T) error {
+		return mm.marshal(enc, v)
+	})
+}
+
+// MaskSet maps Go types to the mask applied to every value of that type, e.g.
+// to always show only "id,name" of a User wherever it appears in a response.
+// It is the non-generic counterpart of MarshalWithMaskFor.
+type MaskSet map[reflect.Type]*Mask
+
+// Marshalers returns a json.Marshalers helper applying the masks of s, with
+// opts, to values of the matching (exact, non-interface) types. It can be
+// joined with MarshalWithMask and other marshalers via json.JoinMarshalers.
+func (s MaskSet) Marshalers(opts ...Option) *json.Marshalers {
+	o := newOptions(opts)
+	byType := make(map[reflect.Type]*maskMarshaler, len(s))
+	for t, m := range s {
+		byType[t] = &maskMarshaler{m: m, o: o}
+	}
+	return json.MarshalToFunc(func(enc *jsontext.Encoder, v any) error {
+		// Functions for interface types receive a pointer to the value.
+		mm, ok := byType[reflect.TypeOf(v).Elem()]
+		if !ok {
+			return json.SkipFunc
+		}
+		return mm.marshal(enc, v)
+	})
+}
@@ -0,0 +1,97 @@
+package kino_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+type setUser struct {
+	ID      int        `json:"id"`
+	Name    string     `json:"name"`
+	Email   string     `json:"email"`
+	Manager *setUser   `json:"manager,omitempty"`
+	Friends []*setUser `json:"friends,omitempty"`
+}
+
+type setResponse struct {
+	Owner  setUser   `json:"owner"`
+	Member *setUser  `json:"member"`
+	Users  []setUser `json:"users"`
+	Count  int       `json:"count"`
+}
+
+func buildSetResponse() setResponse {
+	boss := &setUser{ID: 9, Name: "Boss", Email: "boss@example.com"}
+	return setResponse{
+		Owner:  setUser{ID: 1, Name: "Ada", Email: "ada@example.com", Manager: boss},
+		Member: &setUser{ID: 2, Name: "Bob", Email: "bob@example.com"},
+		Users:  []setUser{{ID: 3, Name: "Cy", Email: "cy@example.com", Friends: []*setUser{boss}}},
+		Count:  3,
+	}
+}
+
+func TestMarshalWithMaskFor(t *testing.T) {
+	userMask, err := kino.ParseMask("id,name,manager,friends")
+	require.NoError(t, err)
+
+	t.Run("every nested value projected", func(t *testing.T) {
+		out, err := json.Marshal(buildSetResponse(), json.WithMarshalers(kino.MarshalWithMaskFor[setUser](userMask)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"owner":{"id":1,"name":"Ada","manager":{"id":9,"name":"Boss"}},
+			"member":{"id":2,"name":"Bob"},
+			"users":[{"id":3,"name":"Cy","friends":[{"id":9,"name":"Boss"}]}],
+			"count":3
+		}`, string(out))
+	})
+
+	t.Run("composes with outer mask including wholesale", func(t *testing.T) {
+		outer, err := kino.ParseMask("owner,users")
+		require.NoError(t, err)
+		out, err := json.Marshal(buildSetResponse(), json.WithMarshalers(json.JoinMarshalers(
+			kino.MarshalWithMask(outer),
+			kino.MarshalWithMaskFor[setUser](userMask),
+		)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"owner":{"id":1,"name":"Ada","manager":{"id":9,"name":"Boss"}},
+			"users":[{"id":3,"name":"Cy","friends":[{"id":9,"name":"Boss"}]}]
+		}`, string(out))
+	})
+
+	t.Run("outer mask narrows type mask", func(t *testing.T) {
+		outer, err := kino.ParseMask("owner:(name,email)")
+		require.NoError(t, err)
+		out, err := json.Marshal(buildSetResponse(), json.WithMarshalers(json.JoinMarshalers(
+			kino.MarshalWithMaskFor[setUser](userMask),
+			kino.MarshalWithMask(outer),
+		)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"owner":{"name":"Ada"}}`, string(out))
+	})
+
+	t.Run("root value of target type", func(t *testing.T) {
+		out, err := json.Marshal(setUser{ID: 1, Name: "Ada", Email: "x"}, json.WithMarshalers(kino.MarshalWithMaskFor[setUser](userMask)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":1,"name":"Ada"}`, string(out))
+	})
+}
+
+func TestMaskSet(t *testing.T) {
+	userMask, err := kino.ParseMask("id")
+	require.NoError(t, err)
+	respMask, err := kino.ParseMask("-count")
+	require.NoError(t, err)
+	set := kino.MaskSet{
+		reflect.TypeFor[setUser]():     userMask,
+		reflect.TypeFor[setResponse](): respMask,
+	}
+	out, err := json.Marshal(buildSetResponse(), json.WithMarshalers(set.Marshalers()))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"owner":{"id":1},"member":{"id":2},"users":[{"id":3}]}`, string(out))
+}
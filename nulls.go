@@ -0,0 +1,67 @@
+package kino
+
+import (
+	"fmt"
+
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// WithExplicitNulls makes MarshalWithMask write every positive leaf of the mask
+// that is absent from a source object (missing, or dropped by omitempty) as
+// null. Absent subtrees are written as objects holding their own positive
+// leaves, so in Positive mode the output shape is fully determined by the mask.
+// Present members are never altered, even when their value is null or not an
+// object.
+func WithExplicitNulls() Option {
+	return func(o *options) {
+		o.explicitNulls = true
+	}
+}
+
+// writeAbsent writes the member key for mask node n whose value is missing from
+// the source. Nodes without positive leaves write nothing.
+func writeAbsent(enc *jsontext.Encoder, key string, n *Node) error {
+	if key == Wildcard || !hasPositive(n) {
+		return nil
+	}
+	if err := enc.WriteToken(jsontext.String(key)); err != nil {
+		return fmt.Errorf("write key %q: %w", key, err)
+	}
+	if !n.hasChildren() || !hasPositiveChildren(n.Children) {
+		if err := enc.WriteToken(jsontext.Null); err != nil {
+			return fmt.Errorf("write null for %q: %w", key, err)
+		}
+		return nil
+	}
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return fmt.Errorf("write '{': %w", err)
+	}
+	for _, k := range orderedKeys(n.Children) {
+		if err := writeAbsent(enc, k, n.Children.Fields[k]); err != nil {
+			return err
+		}
+	}
+	if err := enc.WriteToken(jsontext.EndObject); err != nil {
+		return fmt.Errorf("write '}': %w", err)
+	}
+	return nil
+}
+
+// hasPositive reports whether n is a positive path itself or leads to one
+// through an override subtree.
+func hasPositive(n *Node) bool {
+	if n.Op == Positive {
+		return true
+	}
+	return n.hasChildren() && hasPositiveChildren(n.Children)
+}
+
+// hasPositiveChildren reports whether any node of m leads to a positive path.
+func hasPositiveChildren(m *Mask) bool {
+	for _, n := range m.Fields {
+		if hasPositive(n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestMarshalWithMask_ExplicitNulls(t *testing.T) {
+	type meta struct {
+		Plan string `json:"plan,omitempty"`
+	}
+	type user struct {
+		ID    int    `json:"id"`
+		Email string `json:"email,omitempty"`
+		Meta  *meta  `json:"meta,omitempty"`
+	}
+	marshal := func(t *testing.T, v any, expr string) string {
+		t.Helper()
+		m, err := kino.ParseMask(expr)
+		require.NoError(t, err)
+		out, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m, kino.WithExplicitNulls())))
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	t.Run("omitempty leaf written as null", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"email":null}`, marshal(t, user{ID: 1}, "id,email"))
+	})
+
+	t.Run("absent subtree filled with leaves", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"meta":{"plan":null},"nope":null}`, marshal(t, user{ID: 1}, "id,meta:(plan),nope"))
+	})
+
+	t.Run("present subtree missing leaf", func(t *testing.T) {
+		require.JSONEq(t, `{"meta":{"plan":null,"tier":null}}`, marshal(t, user{Meta: &meta{}}, "meta:(plan,tier)"))
+	})
+
+	t.Run("negative leaves never synthesized", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1}`, marshal(t, user{ID: 1}, "id,-email"))
+	})
+
+	t.Run("override subtree keeps positive leaves", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"meta":{"plan":null}}`, marshal(t, user{ID: 1}, "id,-meta:(plan,-x)"))
+	})
+
+	t.Run("positive subtree without positive leaves is null", func(t *testing.T) {
+		require.JSONEq(t, `{"meta":null}`, marshal(t, user{ID: 1}, "meta:(-plan)"))
+	})
+
+	t.Run("array elements filled", func(t *testing.T) {
+		require.JSONEq(t, `[{"id":1,"email":null},{"id":2,"email":"b"}]`,
+			marshal(t, []user{{ID: 1}, {ID: 2, Email: "b"}}, "id,email"))
+	})
+
+	t.Run("present null left alone", func(t *testing.T) {
+		require.JSONEq(t, `{"meta":null}`, marshal(t, map[string]any{"meta": nil}, "meta:(plan)"))
+	})
+}
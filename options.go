@@ -0,0 +1,68 @@
+package kino
+
+// Option configures how a mask is applied by MarshalWithMask and
+// UnmarshalWithMask. Options that do not concern a given function are ignored
+// by it.
+type Option func(*options)
+
+type options struct {
+	rejectDisallowed bool
+
+	redact   *Placeholder
+	redactAt map[string]Placeholder
+
+	transforms map[string]Transformer
+
+	report *Report
+	strict bool
+
+	explicitNulls bool
+	maskOrder     bool
+
+	observer Observer
+	stats    *Stats
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// needsPath reports whether applying the options requires tracking the path of
+// the member being projected.
+func (o *options) needsPath() bool {
+	return o.rejectDisallowed || len(o.redactAt) > 0 || len(o.transforms) > 0 || o.observer != nil
+}
+
+// tracksMatches reports whether the projector must record which mask nodes
+// matched a member.
+func (o *options) tracksMatches() bool {
+	return o.report != nil || o.strict
+}
+
+// plain reports whether no option changes how members are projected, so that
+// values implementing MaskedMarshaler may project themselves.
+func (o *options) plain() bool {
+	return !o.needsPath() && !o.tracksMatches() && o.redact == nil && o.stats == nil && !o.explicitNulls && !o.maskOrder
+}
+
+// WithMaskOrder makes MarshalWithMask emit the members of Positive-mode objects
+// in the order their fields were listed in the mask (see Mask.Order) instead of
+// source order. Only members that arrive out of order are buffered.
+func WithMaskOrder() Option {
+	return func(o *options) {
+		o.maskOrder = true
+	}
+}
+
+// WithRejectDisallowed makes UnmarshalWithMask fail with a
+// *DisallowedFieldsError when the input sets fields outside the mask, instead
+// of silently dropping them.
+func WithRejectDisallowed() Option {
+	return func(o *options) {
+		o.rejectDisallowed = true
+	}
+}
@@ -0,0 +1,57 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+func TestMarshalWithMask_MaskOrder(t *testing.T) {
+	const doc = `{"a":1,"b":2,"c":{"d":3,"e":4,"f":5},"z":{"x":6,"y":7}}`
+	marshal := func(t *testing.T, expr string, opts ...kino.Option) string {
+		t.Helper()
+		m, err := kino.ParseMask(expr)
+		require.NoError(t, err)
+		opts = append(opts, kino.WithMaskOrder())
+		out, err := json.Marshal(jsontext.Value(doc), json.WithMarshalers(kino.MarshalWithMask(m, opts...)))
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	t.Run("source order already matching", func(t *testing.T) {
+		require.Equal(t, `{"a":1,"c":{"d":3}}`, marshal(t, "a,c:(d)"))
+	})
+
+	t.Run("reordered to mask order", func(t *testing.T) {
+		require.Equal(t, `{"z":{"y":7,"x":6},"c":{"f":5,"d":3},"a":1}`, marshal(t, "z:(y,x),c:(f,d),a"))
+	})
+
+	t.Run("negative leaves do not block", func(t *testing.T) {
+		require.Equal(t, `{"b":2,"a":1}`, marshal(t, "-c,b,a"))
+	})
+
+	t.Run("override subtree ordered", func(t *testing.T) {
+		require.Equal(t, `{"z":{"x":6},"a":1}`, marshal(t, "-z:(x),a"))
+		require.Equal(t, `{"z":{"y":7,"x":6},"a":1}`, marshal(t, "-z:(y,x),a"))
+		require.Equal(t, `{"a":1,"b":2,"c":{"d":3,"e":4,"f":5},"z":{"y":7,"x":6}}`, marshal(t, "-z:(y,x)"))
+	})
+
+	t.Run("negative mode keeps source order", func(t *testing.T) {
+		require.Equal(t, `{"a":1,"c":{"d":3,"e":4,"f":5},"z":{"x":6,"y":7}}`, marshal(t, "-b"))
+	})
+
+	t.Run("explicit nulls placed in mask order", func(t *testing.T) {
+		require.Equal(t, `{"q":null,"b":2,"r":null,"a":1}`, marshal(t, "q,b,r,a", kino.WithExplicitNulls()))
+	})
+
+	t.Run("no recorded order falls back to sorted", func(t *testing.T) {
+		m := maskPositive(map[string]*kino.Node{"b": {Op: kino.Positive}, "a": {Op: kino.Positive}})
+		out, err := json.Marshal(jsontext.Value(`{"b":2,"a":1}`), json.WithMarshalers(kino.MarshalWithMask(m, kino.WithMaskOrder())))
+		require.NoError(t, err)
+		require.Equal(t, `{"a":1,"b":2}`, string(out))
+	})
+}
@@ -0,0 +1,103 @@
+package kino
+
+import "strings"
+
+// FieldPath is the path of a field from the root of a value, one JSON member
+// name per level. It is the building block of the typed accessors generated by
+// cmd/kinogen.
+type FieldPath []string
+
+// PathSelector is implemented by values that select a field path, such as a
+// FieldPath or a generated accessor type embedding one.
+type PathSelector interface {
+	Path() FieldPath
+}
+
+// Path returns p, making FieldPath a PathSelector.
+func (p FieldPath) Path() FieldPath {
+	return p
+}
+
+// Child returns a new path selecting the member name below p.
+func (p FieldPath) Child(name string) FieldPath {
+	return append(p[:len(p):len(p)], name)
+}
+
+// String returns the dotted form of p.
+func (p FieldPath) String() string {
+	return strings.Join(p, ".")
+}
+
+// Include returns a Positive mask selecting exactly the given paths. A path
+// that is a prefix of another selects its whole subtree and takes precedence.
+func Include(paths ...PathSelector) *Mask {
+	root := &Mask{Mode: Positive, Fields: make(map[string]*Node)}
+	for _, s := range paths {
+		p := s.Path()
+		if len(p) == 0 {
+			continue
+		}
+		cur := root
+		for i, name := range p {
+			node, ok := cur.Fields[name]
+			if i == len(p)-1 {
+				if !ok {
+					cur.Order = append(cur.Order, name)
+				}
+				cur.Fields[name] = &Node{Op: Positive}
+				break
+			}
+			if ok && node.Children == nil {
+				break // an ancestor already selects the whole subtree
+			}
+			if !ok {
+				node = &Node{Op: Positive, Children: &Mask{Mode: Positive, Fields: make(map[string]*Node)}}
+				cur.Fields[name] = node
+				cur.Order = append(cur.Order, name)
+			}
+			cur = node.Children
+		}
+	}
+	return root
+}
+
+// Exclude returns a mask dropping the given paths and keeping everything else.
+// With top-level paths only it is a Negative mask. Levels leading to a nested
+// path keep their other members through a Wildcard entry, so that only the
+// named descendants are removed and the mask means the same after a String,
+// JSON or Overlay round trip.
+func Exclude(paths ...PathSelector) *Mask {
+	root := &Mask{Mode: Negative, Fields: make(map[string]*Node)}
+	for _, s := range paths {
+		p := s.Path()
+		if len(p) == 0 {
+			continue
+		}
+		cur := root
+		for i, name := range p {
+			node, ok := cur.Fields[name]
+			if i == len(p)-1 {
+				if !ok {
+					cur.Order = append(cur.Order, name)
+				}
+				cur.Fields[name] = &Node{Op: Negative}
+				break
+			}
+			if ok && node.Op == Negative {
+				break // an ancestor is already dropped
+			}
+			if !ok {
+				if _, ok := cur.Fields[Wildcard]; !ok {
+					cur.Fields[Wildcard] = &Node{Op: Positive}
+					cur.Order = append([]string{Wildcard}, cur.Order...)
+					cur.Mode = Positive
+				}
+				node = &Node{Op: Positive, Children: &Mask{Mode: Negative, Fields: make(map[string]*Node)}}
+				cur.Fields[name] = node
+				cur.Order = append(cur.Order, name)
+			}
+			cur = node.Children
+		}
+	}
+	return root
+}
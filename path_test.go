@@ -0,0 +1,94 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestFieldPath(t *testing.T) {
+	t.Run("child does not alias", func(t *testing.T) {
+		meta := kino.FieldPath{"user", "meta"}
+		plan, internal := meta.Child("plan"), meta.Child("internal")
+		require.Equal(t, "user.meta.plan", plan.String())
+		require.Equal(t, "user.meta.internal", internal.String())
+	})
+
+	t.Run("include", func(t *testing.T) {
+		meta := kino.FieldPath{"meta"}
+		m := kino.Include(kino.FieldPath{"name"}, meta.Child("plan"), kino.FieldPath{"id"})
+		require.Equal(t, kino.Positive, m.Mode)
+		require.Equal(t, "id,meta:(plan),name", m.String())
+		require.Equal(t, []string{"name", "meta", "id"}, m.Order)
+	})
+
+	t.Run("include prefix wins", func(t *testing.T) {
+		meta := kino.FieldPath{"meta"}
+		require.Equal(t, "meta", kino.Include(meta.Child("plan"), meta).String())
+		require.Equal(t, "meta", kino.Include(meta, meta.Child("plan")).String())
+	})
+
+	t.Run("exclude top-level is negative", func(t *testing.T) {
+		m := kino.Exclude(kino.FieldPath{"ssn"}, kino.FieldPath{"email"})
+		require.Equal(t, kino.Negative, m.Mode)
+		require.Equal(t, "-email,-ssn", m.String())
+	})
+
+	t.Run("exclude nested", func(t *testing.T) {
+		meta := kino.FieldPath{"meta"}
+		m := kino.Exclude(kino.FieldPath{"ssn"}, meta.Child("internal"), meta.Child("limits").Child("cpu"))
+		require.Equal(t, "*,meta:(*,-internal,limits:(-cpu)),-ssn", m.String())
+
+		doc := map[string]any{
+			"id":   1,
+			"ssn":  "x",
+			"meta": map[string]any{"plan": "pro", "internal": "y", "limits": map[string]any{"cpu": 1, "mem": 2}},
+		}
+		const want = `{"id":1,"meta":{"plan":"pro","limits":{"mem":2}}}`
+		project := func(t *testing.T, m *kino.Mask) {
+			t.Helper()
+			out, err := json.Marshal(doc, json.WithMarshalers(kino.MarshalWithMask(m)))
+			require.NoError(t, err)
+			require.JSONEq(t, want, string(out))
+		}
+		project(t, m)
+
+		t.Run("string round trip", func(t *testing.T) {
+			parsed, err := kino.ParseMask(m.String())
+			require.NoError(t, err)
+			project(t, parsed)
+		})
+
+		t.Run("json round trip", func(t *testing.T) {
+			data, err := json.Marshal(m)
+			require.NoError(t, err)
+			var decoded kino.Mask
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			project(t, &decoded)
+		})
+
+		t.Run("compact round trip", func(t *testing.T) {
+			data, err := json.Marshal((*kino.CompactMask)(m))
+			require.NoError(t, err)
+			var decoded kino.CompactMask
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			project(t, (*kino.Mask)(&decoded))
+		})
+
+		t.Run("overlay", func(t *testing.T) {
+			project(t, m.Overlay(&kino.Mask{}))
+			project(t, (&kino.Mask{}).Overlay(m))
+		})
+	})
+
+	t.Run("exclude ancestor wins", func(t *testing.T) {
+		meta := kino.FieldPath{"meta"}
+		m := kino.Exclude(meta.Child("internal"), meta)
+		out, err := json.Marshal(map[string]any{"id": 1, "meta": map[string]any{"plan": "pro"}}, json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":1}`, string(out))
+	})
+}
@@ -0,0 +1,63 @@
+package kino
+
+// Prune returns a copy of v containing only what MarshalWithMask would emit for
+// the same mask. It operates on decoded JSON trees: map[string]any objects are
+// filtered key by key and []any arrays apply the mask to every element, using
+// the same Positive/Negative/override semantics as the streaming projector.
+//
+// Maps and slices along kept paths are freshly allocated so the result never
+// aliases containers of v; other values (scalars and any non-JSON-tree types)
+// are carried over as-is. A nil mask returns v unchanged.
+func Prune(v any, m *Mask) any {
+	if m == nil {
+		return v
+	}
+	return pruneValue(v, m)
+}
+
+// pruneValue applies mask to v. A nil mask copies the container tree verbatim.
+func pruneValue(v any, mask *Mask) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		if mask == nil {
+			return copyTree(vv)
+		}
+		out := make(map[string]any, len(vv))
+		for k, child := range vv {
+			keep, sub := mask.Resolve(k)
+			if !keep {
+				continue
+			}
+			out[k] = pruneValue(child, sub)
+		}
+		return out
+	case []any:
+		out := make([]any, len(vv))
+		for i, elem := range vv {
+			out[i] = pruneValue(elem, mask)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// copyTree deep copies the map/slice containers of a decoded JSON tree.
+func copyTree(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(vv))
+		for k, child := range vv {
+			out[k] = copyTree(child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(vv))
+		for i, elem := range vv {
+			out[i] = copyTree(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,79 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestPrune(t *testing.T) {
+	t.Run("nil mask returns input", func(t *testing.T) {
+		in := map[string]any{"a": 1.0}
+		require.Equal(t, in, kino.Prune(in, nil))
+	})
+
+	t.Run("a,c:(d) positive pruned", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(d)")
+		require.NoError(t, err)
+		in := map[string]any{"a": "va", "b": "vb", "c": map[string]any{"d": 1.0, "e": 2.0}}
+		require.Equal(t, map[string]any{"a": "va", "c": map[string]any{"d": 1.0}}, kino.Prune(in, m))
+	})
+
+	t.Run("input never mutated or aliased", func(t *testing.T) {
+		m, err := kino.ParseMask("-b")
+		require.NoError(t, err)
+		inner := map[string]any{"x": 1.0}
+		in := map[string]any{"a": inner, "b": 2.0}
+		got := kino.Prune(in, m).(map[string]any)
+		got["a"].(map[string]any)["x"] = 5.0
+		require.Equal(t, 1.0, inner["x"])
+		require.Contains(t, in, "b")
+	})
+
+	t.Run("scalar root unchanged", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		require.Equal(t, "v", kino.Prune("v", m))
+	})
+}
+
+// TestPrune_MatchesMarshalWithMask checks that Prune and MarshalWithMask agree
+// on every combination of the sample documents and mask expressions below.
+func TestPrune_MatchesMarshalWithMask(t *testing.T) {
+	docs := []string{
+		`{"a":"va","b":"vb","c":{"d":1,"e":2},"z":{"x":10,"y":20}}`,
+		`[{"a":1,"z":{"x":1,"y":2}},{"b":2,"z":[{"x":3},{"y":4}]},5]`,
+		`{"a":null,"c":[1,{"d":2,"e":3}],"z":"scalar"}`,
+		`{"meta":{"plan":"pro","internal":{"secret":true}},"items":[{"id":1,"tags":["x"]}]}`,
+	}
+	exprs := []string{
+		"a",
+		"-b",
+		"a,-b,c:(d,-e),-z:(x)",
+		"-b,-c:(-e)",
+		"-z:(x)",
+		"z:(-x)",
+		"c:(d),z",
+		"meta:(plan),items:(id)",
+		"-meta:(internal:(secret))",
+		"-items:(-tags)",
+	}
+	for _, doc := range docs {
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(doc), &v))
+		for _, expr := range exprs {
+			t.Run(expr+" "+doc, func(t *testing.T) {
+				m, err := kino.ParseMask(expr)
+				require.NoError(t, err)
+				want, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m)))
+				require.NoError(t, err)
+				got, err := json.Marshal(kino.Prune(v, m))
+				require.NoError(t, err)
+				require.JSONEq(t, string(want), string(got))
+			})
+		}
+	}
+}
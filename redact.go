@@ -0,0 +1,98 @@
+package kino
+
+import (
+	"fmt"
+
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// Placeholder describes the value MarshalWithMask writes in place of an
+// excluded member when redaction is enabled (see WithRedaction).
+type Placeholder struct {
+	// value is written verbatim; nil means a type-preserving zero.
+	value jsontext.Value
+}
+
+// RedactString returns a Placeholder that writes s as a JSON string, e.g.
+// RedactString("[REDACTED]").
+func RedactString(s string) Placeholder {
+	v, _ := jsontext.AppendQuote(nil, s) // only fails on invalid UTF-8, which is replaced
+	return Placeholder{value: v}
+}
+
+// RedactNull returns a Placeholder that writes JSON null.
+func RedactNull() Placeholder {
+	return Placeholder{value: jsontext.Value("null")}
+}
+
+// RedactZero returns a Placeholder that writes the zero value of the JSON kind
+// being hidden: "" for strings, 0 for numbers, false for booleans, {} for
+// objects, [] for arrays and null for null.
+func RedactZero() Placeholder {
+	return Placeholder{}
+}
+
+// RedactValue returns a Placeholder that writes the raw JSON value v, or an
+// error if v is not a single valid JSON value.
+func RedactValue(v jsontext.Value) (Placeholder, error) {
+	if !v.IsValid() {
+		return Placeholder{}, fmt.Errorf("invalid JSON placeholder %q", v)
+	}
+	return Placeholder{value: v.Clone()}, nil
+}
+
+// WithRedaction makes MarshalWithMask keep the shape of the document: every
+// member the mask excludes is emitted with placeholder p instead of being
+// removed. Redaction applies at every depth, including array elements and
+// override subtrees; members below a redacted one are hidden along with it.
+func WithRedaction(p Placeholder) Option {
+	return func(o *options) {
+		o.redact = &p
+	}
+}
+
+// WithRedactionAt redacts the member at the dotted path (e.g. "user.email",
+// array indices omitted) with p, overriding the placeholder set by
+// WithRedaction. Used alone, it redacts only that path while other excluded
+// members are still removed.
+func WithRedactionAt(path string, p Placeholder) Option {
+	return func(o *options) {
+		if o.redactAt == nil {
+			o.redactAt = make(map[string]Placeholder)
+		}
+		o.redactAt[path] = p
+	}
+}
+
+// placeholder returns the Placeholder for the excluded member at path, if the
+// member is to be redacted rather than removed.
+func (o *options) placeholder(path string) (Placeholder, bool) {
+	if p, ok := o.redactAt[path]; ok {
+		return p, true
+	}
+	if o.redact != nil {
+		return *o.redact, true
+	}
+	return Placeholder{}, false
+}
+
+// write emits the placeholder for a hidden value of kind k.
+func (p Placeholder) write(enc *jsontext.Encoder, k jsontext.Kind) error {
+	if p.value != nil {
+		return enc.WriteValue(p.value)
+	}
+	switch k {
+	case '"':
+		return enc.WriteToken(jsontext.String(""))
+	case '0':
+		return enc.WriteToken(jsontext.Int(0))
+	case 't', 'f':
+		return enc.WriteToken(jsontext.False)
+	case '{':
+		return enc.WriteValue(jsontext.Value("{}"))
+	case '[':
+		return enc.WriteValue(jsontext.Value("[]"))
+	default:
+		return enc.WriteToken(jsontext.Null)
+	}
+}
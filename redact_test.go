@@ -0,0 +1,66 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+func TestMarshalWithMask_Redaction(t *testing.T) {
+	const doc = `{"a":"va","b":"vb","n":3,"t":true,"nil":null,"c":{"d":1,"e":2},"z":{"x":10,"y":20},"l":[{"x":1,"y":2}]}`
+	marshal := func(t *testing.T, expr string, opts ...kino.Option) string {
+		t.Helper()
+		m, err := kino.ParseMask(expr)
+		require.NoError(t, err)
+		out, err := json.Marshal(jsontext.Value(doc), json.WithMarshalers(kino.MarshalWithMask(m, opts...)))
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	t.Run("string placeholder keeps shape", func(t *testing.T) {
+		got := marshal(t, "a,c:(d)", kino.WithRedaction(kino.RedactString("[REDACTED]")))
+		require.JSONEq(t, `{"a":"va","b":"[REDACTED]","n":"[REDACTED]","t":"[REDACTED]","nil":"[REDACTED]","c":{"d":1,"e":"[REDACTED]"},"z":"[REDACTED]","l":"[REDACTED]"}`, got)
+	})
+
+	t.Run("null placeholder", func(t *testing.T) {
+		got := marshal(t, "-b,-c", kino.WithRedaction(kino.RedactNull()))
+		require.JSONEq(t, `{"a":"va","b":null,"n":3,"t":true,"nil":null,"c":null,"z":{"x":10,"y":20},"l":[{"x":1,"y":2}]}`, got)
+	})
+
+	t.Run("zero placeholder preserves type", func(t *testing.T) {
+		got := marshal(t, "c:(-e)", kino.WithRedaction(kino.RedactZero()))
+		require.JSONEq(t, `{"a":"","b":"","n":0,"t":false,"nil":null,"c":{"d":1,"e":0},"z":{},"l":[]}`, got)
+	})
+
+	t.Run("override subtree and arrays redacted", func(t *testing.T) {
+		got := marshal(t, "-z:(x),l:(x)", kino.WithRedaction(kino.RedactNull()))
+		require.JSONEq(t, `{"a":null,"b":null,"n":null,"t":null,"nil":null,"c":null,"z":{"x":10,"y":null},"l":[{"x":1,"y":null}]}`, got)
+	})
+
+	t.Run("per-path override wins", func(t *testing.T) {
+		stars, err := kino.RedactValue(jsontext.Value(`"***"`))
+		require.NoError(t, err)
+		got := marshal(t, "-b,-z:(x),-l:(x)",
+			kino.WithRedaction(kino.RedactNull()),
+			kino.WithRedactionAt("b", stars),
+			kino.WithRedactionAt("l.y", kino.RedactZero()),
+		)
+		require.JSONEq(t, `{"a":"va","b":"***","n":3,"t":true,"nil":null,"c":{"d":1,"e":2},"z":{"x":10,"y":null},"l":[{"x":1,"y":0}]}`, got)
+	})
+
+	t.Run("invalid raw placeholder rejected", func(t *testing.T) {
+		for _, v := range []string{``, `{`, `"a" "b"`, `nope`} {
+			_, err := kino.RedactValue(jsontext.Value(v))
+			require.Error(t, err, v)
+		}
+	})
+
+	t.Run("per-path only redacts that path", func(t *testing.T) {
+		got := marshal(t, "a,c:(d)", kino.WithRedactionAt("c.e", kino.RedactString("x")))
+		require.JSONEq(t, `{"a":"va","c":{"d":1,"e":"x"}}`, got)
+	})
+}
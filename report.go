@@ -0,0 +1,67 @@
+package kino
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Report collects diagnostics about a MarshalWithMask projection. It is
+// overwritten by every marshal call that uses it, so a Report must not be
+// shared between concurrent calls.
+type Report struct {
+	// Unmatched lists the dotted paths of mask entries that never matched a
+	// member of the projected value, in sorted order. Entries below an
+	// unmatched parent are not listed separately.
+	Unmatched []string
+}
+
+// WithReport makes MarshalWithMask record the mask paths that never matched
+// any member of the value into r (lenient mode).
+func WithReport(r *Report) Option {
+	return func(o *options) {
+		o.report = r
+	}
+}
+
+// WithStrict makes MarshalWithMask fail with an *UnmatchedPathsError when any
+// mask path never matched a member of the value, such as a typo in
+// `meta:(plna)`. Nothing is written when the projection fails.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// UnmatchedPathsError is returned by MarshalWithMask in strict mode when some
+// mask paths never matched a member of the projected value.
+type UnmatchedPathsError struct {
+	Paths []string
+}
+
+func (e *UnmatchedPathsError) Error() string {
+	return fmt.Sprintf("unmatched mask paths: %s", strings.Join(e.Paths, ", "))
+}
+
+// unmatchedPaths returns the sorted dotted paths of the nodes of m missing
+// from matched. Children of an unmatched node are not visited.
+func unmatchedPaths(m *Mask, matched map[*Node]bool) []string {
+	var res []string
+	var walk func(mm *Mask, prefix string)
+	walk = func(mm *Mask, prefix string) {
+		for _, k := range sortedKeys(mm) {
+			node := mm.Fields[k]
+			path := prefix + k
+			if !matched[node] {
+				res = append(res, path)
+				continue
+			}
+			if node.hasChildren() {
+				walk(node.Children, path+".")
+			}
+		}
+	}
+	if m != nil {
+		walk(m, "")
+	}
+	return res
+}
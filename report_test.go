@@ -0,0 +1,61 @@
+package kino_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+func TestMarshalWithMask_Report(t *testing.T) {
+	t.Run("all matched empty report", func(t *testing.T) {
+		m, err := kino.ParseMask("a,-b,c:(d,-e),-z:(x)")
+		require.NoError(t, err)
+		var r kino.Report
+		_, err = json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithMask(m, kino.WithReport(&r))))
+		require.NoError(t, err)
+		require.Empty(t, r.Unmatched)
+	})
+
+	t.Run("typos reported leniently", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(dd,e),-zz:(x),q:(r)")
+		require.NoError(t, err)
+		var r kino.Report
+		out, err := json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithMask(m, kino.WithReport(&r))))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va","c":{"e":2}}`, string(out))
+		require.Equal(t, []string{"c.dd", "q", "zz"}, r.Unmatched)
+	})
+
+	t.Run("match in any array element counts", func(t *testing.T) {
+		m, err := kino.ParseMask("a:(x,y)")
+		require.NoError(t, err)
+		var r kino.Report
+		_, err = json.Marshal(map[string]any{"a": []any{map[string]any{"x": 1}, map[string]any{"y": 2}}},
+			json.WithMarshalers(kino.MarshalWithMask(m, kino.WithReport(&r))))
+		require.NoError(t, err)
+		require.Empty(t, r.Unmatched)
+	})
+
+	t.Run("strict mode typed error", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(plna)")
+		require.NoError(t, err)
+		var r kino.Report
+		out, err := json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithMask(m, kino.WithStrict(), kino.WithReport(&r))))
+		var uerr *kino.UnmatchedPathsError
+		require.ErrorAs(t, err, &uerr)
+		require.Equal(t, []string{"c.plna"}, uerr.Paths)
+		require.Equal(t, []string{"c.plna"}, r.Unmatched)
+		require.Empty(t, out)
+	})
+
+	t.Run("strict mode success writes output", func(t *testing.T) {
+		m, err := kino.ParseMask("a,c:(d)")
+		require.NoError(t, err)
+		out, err := json.Marshal(buildSample(), json.WithMarshalers(kino.MarshalWithMask(m, kino.WithStrict())))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":"va","c":{"d":1}}`, string(out))
+	})
+}
@@ -0,0 +1,321 @@
+// Package cbor applies kino masks to CBOR (RFC 8949) encoded data items. The
+// input is walked token by token: retained members are copied verbatim and
+// excluded ones are skipped by their length prefixes without being decoded.
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/calumari/kino"
+)
+
+// maxDepth bounds the nesting of arrays, maps and tags.
+const maxDepth = 10000
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+
+	infoIndefinite = 31
+	breakByte      = 0xff
+)
+
+var errTruncated = errors.New("cbor: unexpected end of data")
+
+// Project returns the single CBOR data item in data projected by m, resolving
+// keys with kino.Mask.Resolve as kino.MarshalWithMask does. Maps are
+// projected by their text string keys; other keys cannot be named by a mask
+// and only survive in Negative mode. Array elements inherit the mask of their
+// parent and tags are projected through. Retained maps are re-encoded with a
+// definite length; everything else is copied byte for byte. A nil mask
+// returns the item unchanged.
+func Project(data []byte, m *kino.Mask) ([]byte, error) {
+	p := projector{data: data}
+	out, err := p.project(nil, m, 0)
+	if err != nil {
+		return nil, err
+	}
+	if p.off != len(data) {
+		return nil, fmt.Errorf("cbor: %d bytes of trailing data", len(data)-p.off)
+	}
+	return out, nil
+}
+
+// projector walks data from off.
+type projector struct {
+	data []byte
+	off  int
+}
+
+// header reads the initial byte and argument of the item at p.off.
+// indefinite reports the indefinite-length form (additional info 31).
+func (p *projector) header() (major byte, arg uint64, indefinite bool, err error) {
+	if p.off >= len(p.data) {
+		return 0, 0, false, errTruncated
+	}
+	b := p.data[p.off]
+	major, info := b>>5, b&0x1f
+	p.off++
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info <= 27:
+		n := 1 << (info - 24)
+		if len(p.data)-p.off < n {
+			return 0, 0, false, errTruncated
+		}
+		buf := p.data[p.off : p.off+n]
+		p.off += n
+		switch n {
+		case 1:
+			arg = uint64(buf[0])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(buf))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(buf))
+		default:
+			arg = binary.BigEndian.Uint64(buf)
+		}
+		return major, arg, false, nil
+	case info == infoIndefinite && (major >= majorBytes && major <= majorMap || major == majorSimple):
+		return major, 0, true, nil
+	}
+	return 0, 0, false, fmt.Errorf("cbor: invalid additional information %d at offset %d", info, p.off-1)
+}
+
+// skip advances past one data item without decoding it.
+func (p *projector) skip(depth int) error {
+	if depth > maxDepth {
+		return errors.New("cbor: maximum nesting depth exceeded")
+	}
+	start := p.off
+	major, arg, indefinite, err := p.header()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorBytes, majorText:
+		if indefinite {
+			return p.skipChunks(major)
+		}
+		if uint64(len(p.data)-p.off) < arg {
+			return errTruncated
+		}
+		p.off += int(arg)
+	case majorArray, majorMap:
+		items := arg
+		if major == majorMap {
+			items *= 2
+		}
+		if indefinite {
+			for !p.atBreak() {
+				if err := p.skip(depth + 1); err != nil {
+					return err
+				}
+			}
+			p.off++
+			return nil
+		}
+		for ; items > 0; items-- {
+			if err := p.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+	case majorTag:
+		return p.skip(depth + 1)
+	case majorSimple:
+		if indefinite {
+			return fmt.Errorf("cbor: unexpected break at offset %d", start)
+		}
+	}
+	return nil
+}
+
+// skipChunks advances past the definite-length chunks of an indefinite-length
+// byte or text string and its break.
+func (p *projector) skipChunks(major byte) error {
+	for !p.atBreak() {
+		m, n, indefinite, err := p.header()
+		if err != nil {
+			return err
+		}
+		if m != major || indefinite {
+			return fmt.Errorf("cbor: invalid chunk in indefinite-length string at offset %d", p.off)
+		}
+		if uint64(len(p.data)-p.off) < n {
+			return errTruncated
+		}
+		p.off += int(n)
+	}
+	p.off++
+	return nil
+}
+
+// atBreak reports whether the next byte is the break stop code. It reports
+// false at the end of data so that the following read fails.
+func (p *projector) atBreak() bool {
+	return p.off < len(p.data) && p.data[p.off] == breakByte
+}
+
+// key reads the map key at p.off. ok is false for keys that are not text
+// strings; they are skipped.
+func (p *projector) key() (name string, ok bool, err error) {
+	start := p.off
+	major, n, indefinite, err := p.header()
+	if err != nil {
+		return "", false, err
+	}
+	if major != majorText {
+		p.off = start
+		return "", false, p.skip(1)
+	}
+	if !indefinite {
+		if uint64(len(p.data)-p.off) < n {
+			return "", false, errTruncated
+		}
+		name = string(p.data[p.off : p.off+int(n)])
+		p.off += int(n)
+	} else {
+		var buf []byte
+		for !p.atBreak() {
+			m, n, indefinite, err := p.header()
+			if err != nil {
+				return "", false, err
+			}
+			if m != majorText || indefinite {
+				return "", false, fmt.Errorf("cbor: invalid chunk in indefinite-length string at offset %d", p.off)
+			}
+			if uint64(len(p.data)-p.off) < n {
+				return "", false, errTruncated
+			}
+			buf = append(buf, p.data[p.off:p.off+int(n)]...)
+			p.off += int(n)
+		}
+		p.off++
+		name = string(buf)
+	}
+	if !utf8.ValidString(name) {
+		return "", false, fmt.Errorf("cbor: invalid UTF-8 in map key at offset %d", start)
+	}
+	return name, true, nil
+}
+
+// member is a retained map entry found while scanning a map.
+type member struct {
+	key   []byte // encoded key
+	start int    // offset of the value
+	sub   *kino.Mask
+}
+
+// project appends the item at p.off projected by m to dst.
+func (p *projector) project(dst []byte, m *kino.Mask, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errors.New("cbor: maximum nesting depth exceeded")
+	}
+	start := p.off
+	if m == nil {
+		if err := p.skip(depth); err != nil {
+			return nil, err
+		}
+		return append(dst, p.data[start:p.off]...), nil
+	}
+	major, arg, indefinite, err := p.header()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case majorArray:
+		dst = append(dst, p.data[start:p.off]...)
+		if indefinite {
+			for !p.atBreak() {
+				if dst, err = p.project(dst, m, depth+1); err != nil {
+					return nil, err
+				}
+			}
+			p.off++
+			return append(dst, breakByte), nil
+		}
+		for i := uint64(0); i < arg; i++ {
+			if dst, err = p.project(dst, m, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	case majorMap:
+		return p.projectMap(dst, m, arg, indefinite, depth)
+	case majorTag:
+		dst = append(dst, p.data[start:p.off]...)
+		return p.project(dst, m, depth+1)
+	}
+	p.off = start
+	if err := p.skip(depth); err != nil {
+		return nil, err
+	}
+	return append(dst, p.data[start:p.off]...), nil
+}
+
+// projectMap appends the map whose header was just read. A first pass finds
+// the retained members, so that the output header can carry their count; a
+// second pass projects their values.
+func (p *projector) projectMap(dst []byte, m *kino.Mask, n uint64, indefinite bool, depth int) ([]byte, error) {
+	var kept []member
+	for i := uint64(0); indefinite && !p.atBreak() || !indefinite && i < n; i++ {
+		keyStart := p.off
+		name, ok, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		keep, sub := m.Mode == kino.Negative, (*kino.Mask)(nil)
+		if ok {
+			keep, sub = m.Resolve(name)
+		}
+		if keep {
+			kept = append(kept, member{key: p.data[keyStart:p.off], start: p.off, sub: sub})
+		}
+		if err := p.skip(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	if indefinite {
+		p.off++ // break
+	}
+	end := p.off
+
+	dst = appendHeader(dst, majorMap, uint64(len(kept)))
+	for _, mem := range kept {
+		dst = append(dst, mem.key...)
+		p.off = mem.start
+		var err error
+		if dst, err = p.project(dst, mem.sub, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	p.off = end
+	return dst, nil
+}
+
+// appendHeader appends the shortest header for major type major with
+// argument n.
+func appendHeader(dst []byte, major byte, n uint64) []byte {
+	mt := major << 5
+	switch {
+	case n < 24:
+		return append(dst, mt|byte(n))
+	case n <= 0xff:
+		return append(dst, mt|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(dst, mt|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(dst, mt|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, mt|27), n)
+}
@@ -0,0 +1,181 @@
+package cbor
+
+import (
+	"testing"
+
+	"github.com/calumari/kino"
+	"github.com/stretchr/testify/require"
+)
+
+// obj is an ordered CBOR map used to build test documents.
+type obj []kv
+
+type kv struct {
+	k any
+	v any
+}
+
+// enc encodes v with definite lengths and shortest headers.
+func enc(v any) []byte {
+	return appendValue(nil, v)
+}
+
+func appendValue(dst []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, 0xf6)
+	case bool:
+		if v {
+			return append(dst, 0xf5)
+		}
+		return append(dst, 0xf4)
+	case int:
+		if v < 0 {
+			return appendHeader(dst, majorNegInt, uint64(-1-v))
+		}
+		return appendHeader(dst, majorUint, uint64(v))
+	case string:
+		return append(appendHeader(dst, majorText, uint64(len(v))), v...)
+	case []byte:
+		return append(appendHeader(dst, majorBytes, uint64(len(v))), v...)
+	case []any:
+		dst = appendHeader(dst, majorArray, uint64(len(v)))
+		for _, e := range v {
+			dst = appendValue(dst, e)
+		}
+		return dst
+	case obj:
+		dst = appendHeader(dst, majorMap, uint64(len(v)))
+		for _, e := range v {
+			dst = appendValue(dst, e.k)
+			dst = appendValue(dst, e.v)
+		}
+		return dst
+	}
+	panic("unsupported test value")
+}
+
+func sampleDoc() obj {
+	return obj{
+		{"a", "va"},
+		{"b", []byte("vb")},
+		{"c", obj{{"d", 1}, {"e", -2}}},
+		{"z", obj{{"x", 10}, {"y", 1000}}},
+	}
+}
+
+func TestProject(t *testing.T) {
+	project := func(t *testing.T, doc any, mask string) []byte {
+		t.Helper()
+		m, err := kino.ParseMask(mask)
+		require.NoError(t, err)
+		out, err := Project(enc(doc), m)
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("nil mask returns item unchanged", func(t *testing.T) {
+		data := enc(sampleDoc())
+		out, err := Project(data, nil)
+		require.NoError(t, err)
+		require.Equal(t, data, out)
+	})
+
+	t.Run("a,c:(d) positive", func(t *testing.T) {
+		want := obj{{"a", "va"}, {"c", obj{{"d", 1}}}}
+		require.Equal(t, enc(want), project(t, sampleDoc(), "a,c:(d)"))
+	})
+
+	t.Run("-b,-c:(-e) negative", func(t *testing.T) {
+		want := obj{{"a", "va"}, {"c", obj{}}, {"z", obj{{"x", 10}, {"y", 1000}}}}
+		require.Equal(t, enc(want), project(t, sampleDoc(), "-b,-c:(-e)"))
+	})
+
+	t.Run("a,-b,c:(d,-e),-z:(x) mixed", func(t *testing.T) {
+		want := obj{{"a", "va"}, {"c", obj{{"d", 1}}}, {"z", obj{{"x", 10}}}}
+		require.Equal(t, enc(want), project(t, sampleDoc(), "a,-b,c:(d,-e),-z:(x)"))
+	})
+
+	t.Run("arrays inherit mask", func(t *testing.T) {
+		doc := []any{sampleDoc(), "s", sampleDoc()}
+		want := []any{obj{{"z", obj{{"x", 10}}}}, "s", obj{{"z", obj{{"x", 10}}}}}
+		require.Equal(t, enc(want), project(t, doc, "z:(x)"))
+	})
+
+	t.Run("wildcard applies to unlisted keys", func(t *testing.T) {
+		doc := obj{{"items", obj{{"k1", obj{{"id", 1}, {"v", 2}}}, {"k2", obj{{"id", 3}, {"v", 4}}}}}}
+		want := obj{{"items", obj{{"k1", obj{{"id", 1}}}, {"k2", obj{{"id", 3}}}}}}
+		require.Equal(t, enc(want), project(t, doc, "items:(*:(id))"))
+	})
+
+	t.Run("non-text keys kept only in negative mode", func(t *testing.T) {
+		doc := obj{{1, "one"}, {"a", "va"}, {"b", "vb"}}
+		require.Equal(t, enc(obj{{"a", "va"}}), project(t, doc, "a"))
+		require.Equal(t, enc(obj{{1, "one"}, {"a", "va"}}), project(t, doc, "-b"))
+	})
+
+	t.Run("tags are projected through", func(t *testing.T) {
+		data := append([]byte{0xd8, 0x37}, enc(sampleDoc())...) // tag 55
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		out, err := Project(data, m)
+		require.NoError(t, err)
+		require.Equal(t, append([]byte{0xd8, 0x37}, enc(obj{{"a", "va"}})...), out)
+	})
+
+	t.Run("indefinite lengths", func(t *testing.T) {
+		data := []byte{
+			0xbf,                  // map(*)
+			0x7f, 0x61, 'a', 0xff, // "a" as chunked text
+			0x9f, 0x01, 0x02, 0xff, // [_ 1, 2]
+			0x61, 'b',
+			0x5f, 0x41, 0x00, 0x41, 0x01, 0xff, // (_ h'00', h'01')
+			0xff,
+		}
+		m, err := kino.ParseMask("-b")
+		require.NoError(t, err)
+		out, err := Project(data, m)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xa1, 0x7f, 0x61, 'a', 0xff, 0x9f, 0x01, 0x02, 0xff}, out)
+	})
+
+	t.Run("excluded values are skipped without decoding", func(t *testing.T) {
+		// Invalid UTF-8 and an unassigned simple value under an excluded key.
+		data := []byte{0xa2, 0x61, 'a', 0x62, 0xff, 0xfe, 0x61, 'b', 0xf0}
+		m, err := kino.ParseMask("b")
+		require.NoError(t, err)
+		out, err := Project(data, m)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xa1, 0x61, 'b', 0xf0}, out)
+	})
+
+	t.Run("large maps use wider headers", func(t *testing.T) {
+		var doc obj
+		for i := range 30 {
+			doc = append(doc, kv{string(rune('A' + i)), i})
+		}
+		out := project(t, doc, "-A")
+		require.Equal(t, enc(doc[1:]), out)
+		require.Equal(t, []byte{0xb8, 29}, out[:2])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		for name, data := range map[string][]byte{
+			"truncated":       enc(sampleDoc())[:10],
+			"trailing data":   append(enc(sampleDoc()), 0x00),
+			"reserved info":   {0x1c},
+			"stray break":     {0xa1, 0x61, 'a', 0xff},
+			"bad chunk":       {0x7f, 0x41, 0x00, 0xff},
+			"invalid utf8":    {0xa1, 0x61, 0xff, 0x00},
+			"empty":           {},
+			"truncated array": {0x82, 0x01},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Project(data, m)
+				require.Error(t, err)
+			})
+		}
+	})
+}
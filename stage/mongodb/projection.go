@@ -1,9 +1,10 @@
 package mongo
 
 // Package mongo exposes helpers to translate kino masks into MongoDB projection
-// documents (bson.D)
+// documents (bson.D) and to prune already loaded BSON documents
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/calumari/kino"
@@ -14,13 +15,29 @@ import (
 //   - Positive root: inclusion list of leaf positive paths (overrides honored).
 //   - Negative root with only simple top-level negative leaves: exclusion doc.
 //   - Any negative-with-children override forces inclusion expansion.
+//   - Root with a kino.Wildcard entry keeping unlisted fields: exclusion doc of
+//     the negative leaves below levels that keep unlisted fields too.
 //
 // Limitations: Mixed inclusion/exclusion at top-level (invalid in Mongo) are
-// resolved via inclusion expansion.
+// resolved via inclusion expansion. MongoDB projections cannot name unknown
+// fields, so levels keeping fields they do not list (a kino.Wildcard entry or
+// a negative-only subtree) are included whole, and narrowing them (e.g.
+// "extra:(*:(plan))") or narrowing below an exclusion doc is not expressed.
+// The projection then fetches a superset of what the mask keeps; apply Prune
+// to the loaded documents for the exact result.
+//
+// Elements are sorted by path, so equal masks always yield byte-identical
+// BSON regardless of how they were built.
 func Project(m *kino.Mask) bson.D {
 	if m == nil || len(m.Fields) == 0 {
 		return bson.D{}
 	}
+	if n, ok := m.Fields[kino.Wildcard]; ok && n.Op == kino.Positive {
+		out := bson.D{}
+		excludeUnder(m, nil, &out)
+		sortByKey(out)
+		return out
+	}
 
 	var needsInclusion func(mm *kino.Mask) bool
 	needsInclusion = func(mm *kino.Mask) bool {
@@ -43,6 +60,7 @@ func Project(m *kino.Mask) bson.D {
 				out = append(out, bson.E{Key: name, Value: 0})
 			}
 		}
+		sortByKey(out)
 		return out
 	}
 
@@ -51,15 +69,22 @@ func Project(m *kino.Mask) bson.D {
 	var walk func(mm *kino.Mask)
 	walk = func(mm *kino.Mask) {
 		for name, node := range mm.Fields {
+			if name == kino.Wildcard {
+				continue // levels with one are included whole
+			}
 			stack = append(stack, name)
 			if node.Op == kino.Negative {
 				if node.Children != nil && len(node.Children.Fields) > 0 {
-					walk(node.Children)
+					if keepsUnlisted(node.Children, true) {
+						inc[strings.Join(stack, ".")] = struct{}{}
+					} else {
+						walk(node.Children)
+					}
 				}
 				stack = stack[:len(stack)-1]
 				continue
 			}
-			if node.Children != nil && len(node.Children.Fields) > 0 {
+			if node.Children != nil && len(node.Children.Fields) > 0 && !keepsUnlisted(node.Children, false) {
 				walk(node.Children)
 				stack = stack[:len(stack)-1]
 				continue
@@ -74,5 +99,39 @@ func Project(m *kino.Mask) bson.D {
 	for k := range inc {
 		out = append(out, bson.E{Key: k, Value: 1})
 	}
+	sortByKey(out)
 	return out
 }
+
+// keepsUnlisted reports whether the subtree mm keeps fields it does not list:
+// through a kino.Wildcard entry, or as a negative-only subtree of a positive
+// node. Children of an override (override set) only keep what they list.
+func keepsUnlisted(mm *kino.Mask, override bool) bool {
+	if n, ok := mm.Fields[kino.Wildcard]; ok {
+		return n.Op == kino.Positive || n.Children != nil && len(n.Children.Fields) > 0
+	}
+	return !override && mm.Mode == kino.Negative
+}
+
+// excludeUnder appends an exclusion for every negative leaf of mm, a level
+// keeping unlisted fields at path, descending into positive subtrees that
+// keep unlisted fields as well. Narrower subtrees are fetched whole.
+func excludeUnder(mm *kino.Mask, path []string, out *bson.D) {
+	for name, node := range mm.Fields {
+		if name == kino.Wildcard {
+			continue
+		}
+		p := append(slices.Clip(path), name)
+		switch {
+		case node.Op == kino.Negative && (node.Children == nil || len(node.Children.Fields) == 0):
+			*out = append(*out, bson.E{Key: strings.Join(p, "."), Value: 0})
+		case node.Op == kino.Positive && node.Children != nil && len(node.Children.Fields) > 0 && keepsUnlisted(node.Children, false):
+			excludeUnder(node.Children, p, out)
+		}
+	}
+}
+
+// sortByKey orders d by element key.
+func sortByKey(d bson.D) {
+	slices.SortFunc(d, func(a, b bson.E) int { return strings.Compare(a.Key, b.Key) })
+}
@@ -26,7 +26,7 @@ func TestToProjection(t *testing.T) {
 
 		m, err := kino.ParseMask("a,c:(d)")
 		require.NoError(t, err)
-		require.ElementsMatch(t, want, Project(m))
+		require.Equal(t, want, Project(m))
 	})
 
 	t.Run("-a,-b negative simple excludes projection", func(t *testing.T) {
@@ -37,7 +37,7 @@ func TestToProjection(t *testing.T) {
 
 		m, err := kino.ParseMask("-a,-b")
 		require.NoError(t, err)
-		require.ElementsMatch(t, want, Project(m))
+		require.Equal(t, want, Project(m))
 	})
 
 	t.Run("-z:(x) override projection", func(t *testing.T) {
@@ -47,7 +47,7 @@ func TestToProjection(t *testing.T) {
 
 		m, err := kino.ParseMask("-z:(x)")
 		require.NoError(t, err)
-		require.ElementsMatch(t, want, Project(m))
+		require.Equal(t, want, Project(m))
 	})
 
 	t.Run("a,-b,c:(d,-e),-z:(x) mixed projection", func(t *testing.T) {
@@ -59,7 +59,7 @@ func TestToProjection(t *testing.T) {
 
 		m, err := kino.ParseMask("a,-b,c:(d,-e),-z:(x)")
 		require.NoError(t, err)
-		require.ElementsMatch(t, want, Project(m))
+		require.Equal(t, want, Project(m))
 	})
 
 	t.Run("-a:(-b:(-c:(d:(e,-f),-g,y:(z,-w)))) negative with children projection", func(t *testing.T) {
@@ -71,7 +71,7 @@ func TestToProjection(t *testing.T) {
 		m, err := kino.ParseMask("-a:(-b:(-c:(d:(e,-f),-g,y:(z,-w))))")
 		m.Mode = kino.Negative // force negative mode - silly little edge case of an unsupported feature
 		require.NoError(t, err)
-		require.ElementsMatch(t, want, Project(m))
+		require.Equal(t, want, Project(m))
 	})
 
 	t.Run("a,-b:(c),d:(e),f:(g:(h)),-i mixed inclusion exclusion projection", func(t *testing.T) {
@@ -84,6 +84,70 @@ func TestToProjection(t *testing.T) {
 
 		m, err := kino.ParseMask("a,-b:(c),d:(e),f:(g:(h)),-i")
 		require.NoError(t, err)
-		require.ElementsMatch(t, want, Project(m))
+		require.Equal(t, want, Project(m))
+	})
+
+	t.Run("equal masks yield identical bson", func(t *testing.T) {
+		a, err := kino.ParseMask("z,c:(e,d),a:(y,x)")
+		require.NoError(t, err)
+		b, err := kino.ParseMask("a:(x,y),c:(d,e),z")
+		require.NoError(t, err)
+
+		want := bson.D{
+			{Key: "a.x", Value: 1},
+			{Key: "a.y", Value: 1},
+			{Key: "c.d", Value: 1},
+			{Key: "c.e", Value: 1},
+			{Key: "z", Value: 1},
+		}
+		require.Equal(t, want, Project(a))
+
+		rawA, err := bson.Marshal(Project(a))
+		require.NoError(t, err)
+		for range 20 {
+			rawB, err := bson.Marshal(Project(b))
+			require.NoError(t, err)
+			require.Equal(t, rawA, rawB)
+		}
+	})
+
+	t.Run("wildcard root excludes negative descendants", func(t *testing.T) {
+		want := bson.D{
+			{Key: "meta.internal", Value: 0},
+			{Key: "ssn", Value: 0},
+		}
+		for _, expr := range []string{"*,-ssn,meta:(-internal)", "*,-ssn,meta:(*,-internal)", "*,-ssn,meta:(*,-internal),extra:(plan),other:(-*:(x))"} {
+			m, err := kino.ParseMask(expr)
+			require.NoError(t, err)
+			require.Equal(t, want, Project(m), expr)
+		}
+
+		m := kino.Exclude(kino.FieldPath{"meta", "internal"}, kino.FieldPath{"ssn"})
+		require.Equal(t, want, Project(m))
+	})
+
+	t.Run("wildcard levels included whole", func(t *testing.T) {
+		want := bson.D{
+			{Key: "extra", Value: 1},
+			{Key: "id", Value: 1},
+			{Key: "meta", Value: 1},
+			{Key: "z", Value: 1},
+		}
+
+		m, err := kino.ParseMask("id,extra:(*:(plan)),meta:(-internal),-z:(*)")
+		require.NoError(t, err)
+		require.Equal(t, want, Project(m))
+	})
+
+	t.Run("-c,-a,-b exclusion sorted", func(t *testing.T) {
+		want := bson.D{
+			{Key: "a", Value: 0},
+			{Key: "b", Value: 0},
+			{Key: "c", Value: 0},
+		}
+
+		m, err := kino.ParseMask("-c,-a,-b")
+		require.NoError(t, err)
+		require.Equal(t, want, Project(m))
 	})
 }
@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"github.com/calumari/kino"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+)
+
+// Prune returns a copy of doc holding only the elements m retains, resolving
+// keys with kino.Mask.Resolve as kino.MarshalWithMask does.
+// Embedded documents are pruned with the mask of their key and every element
+// of an array inherits the mask of the array, so arrays of subdocuments are
+// pruned element by element. Retained elements that need no further pruning
+// are copied verbatim. A nil mask returns doc unchanged.
+func Prune(doc bson.Raw, m *kino.Mask) (bson.Raw, error) {
+	if err := bsoncore.Document(doc).Validate(); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return doc, nil
+	}
+	out, err := pruneDocument(nil, bsoncore.Document(doc), m)
+	if err != nil {
+		return nil, err
+	}
+	return bson.Raw(out), nil
+}
+
+// pruneDocument appends doc pruned by m to dst.
+func pruneDocument(dst []byte, doc bsoncore.Document, m *kino.Mask) ([]byte, error) {
+	elems, err := doc.Elements()
+	if err != nil {
+		return nil, err
+	}
+	idx, dst := bsoncore.AppendDocumentStart(dst)
+	for _, e := range elems {
+		keep, sub := m.Resolve(e.Key())
+		if !keep {
+			continue
+		}
+		if dst, err = appendElement(dst, e, sub); err != nil {
+			return nil, err
+		}
+	}
+	return bsoncore.AppendDocumentEnd(dst, idx)
+}
+
+// appendElement appends e with its value pruned by m. Arrays keep their
+// elements (and thus their keys) and pass m down to each of them.
+func appendElement(dst []byte, e bsoncore.Element, m *kino.Mask) ([]byte, error) {
+	v := e.Value()
+	if m == nil || v.Type != bsoncore.TypeEmbeddedDocument && v.Type != bsoncore.TypeArray {
+		return append(dst, e...), nil
+	}
+	dst = bsoncore.AppendHeader(dst, v.Type, e.Key())
+	if v.Type == bsoncore.TypeEmbeddedDocument {
+		return pruneDocument(dst, v.Data, m)
+	}
+	elems, err := bsoncore.Document(v.Data).Elements()
+	if err != nil {
+		return nil, err
+	}
+	idx, dst := bsoncore.AppendArrayStart(dst)
+	for _, el := range elems {
+		if dst, err = appendElement(dst, el, m); err != nil {
+			return nil, err
+		}
+	}
+	return bsoncore.AppendArrayEnd(dst, idx)
+}
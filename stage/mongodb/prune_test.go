@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/calumari/kino"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestPrune(t *testing.T) {
+	sample := bson.D{
+		{Key: "a", Value: "va"},
+		{Key: "b", Value: "vb"},
+		{Key: "c", Value: bson.D{{Key: "d", Value: int32(1)}, {Key: "e", Value: int32(2)}}},
+		{Key: "z", Value: bson.D{{Key: "x", Value: int32(10)}, {Key: "y", Value: int32(20)}}},
+	}
+
+	prune := func(t *testing.T, doc bson.D, mask string) bson.Raw {
+		t.Helper()
+		raw, err := bson.Marshal(doc)
+		require.NoError(t, err)
+		m, err := kino.ParseMask(mask)
+		require.NoError(t, err)
+		out, err := Prune(raw, m)
+		require.NoError(t, err)
+		return out
+	}
+	marshal := func(t *testing.T, doc bson.D) bson.Raw {
+		t.Helper()
+		raw, err := bson.Marshal(doc)
+		require.NoError(t, err)
+		return raw
+	}
+
+	t.Run("nil mask returns document unchanged", func(t *testing.T) {
+		raw := marshal(t, sample)
+		out, err := Prune(raw, nil)
+		require.NoError(t, err)
+		require.Equal(t, raw, out)
+	})
+
+	t.Run("a,c:(d) positive", func(t *testing.T) {
+		want := bson.D{
+			{Key: "a", Value: "va"},
+			{Key: "c", Value: bson.D{{Key: "d", Value: int32(1)}}},
+		}
+		require.Equal(t, marshal(t, want), prune(t, sample, "a,c:(d)"))
+	})
+
+	t.Run("-b,-c:(-e) negative", func(t *testing.T) {
+		want := bson.D{
+			{Key: "a", Value: "va"},
+			{Key: "c", Value: bson.D{}},
+			{Key: "z", Value: bson.D{{Key: "x", Value: int32(10)}, {Key: "y", Value: int32(20)}}},
+		}
+		require.Equal(t, marshal(t, want), prune(t, sample, "-b,-c:(-e)"))
+	})
+
+	t.Run("a,-b,c:(d,-e),-z:(x) mixed", func(t *testing.T) {
+		want := bson.D{
+			{Key: "a", Value: "va"},
+			{Key: "c", Value: bson.D{{Key: "d", Value: int32(1)}}},
+			{Key: "z", Value: bson.D{{Key: "x", Value: int32(10)}}},
+		}
+		require.Equal(t, marshal(t, want), prune(t, sample, "a,-b,c:(d,-e),-z:(x)"))
+	})
+
+	t.Run("arrays of subdocuments inherit mask", func(t *testing.T) {
+		doc := bson.D{
+			{Key: "items", Value: bson.A{
+				bson.D{{Key: "id", Value: int32(1)}, {Key: "secret", Value: "s1"}},
+				"scalar",
+				bson.A{bson.D{{Key: "id", Value: int32(2)}, {Key: "secret", Value: "s2"}}},
+			}},
+			{Key: "total", Value: int32(2)},
+		}
+		want := bson.D{
+			{Key: "items", Value: bson.A{
+				bson.D{{Key: "id", Value: int32(1)}},
+				"scalar",
+				bson.A{bson.D{{Key: "id", Value: int32(2)}}},
+			}},
+		}
+		require.Equal(t, marshal(t, want), prune(t, doc, "items:(id)"))
+	})
+
+	t.Run("wildcard applies to unlisted keys", func(t *testing.T) {
+		doc := bson.D{{Key: "byID", Value: bson.D{
+			{Key: "k1", Value: bson.D{{Key: "id", Value: int32(1)}, {Key: "v", Value: int32(2)}}},
+			{Key: "k2", Value: bson.D{{Key: "id", Value: int32(3)}, {Key: "v", Value: int32(4)}}},
+		}}}
+		want := bson.D{{Key: "byID", Value: bson.D{
+			{Key: "k1", Value: bson.D{{Key: "id", Value: int32(1)}}},
+			{Key: "k2", Value: bson.D{{Key: "id", Value: int32(3)}}},
+		}}}
+		require.Equal(t, marshal(t, want), prune(t, doc, "byID:(*:(id))"))
+	})
+
+	t.Run("retained non-document values copied verbatim", func(t *testing.T) {
+		oid := bson.NewObjectID()
+		doc := bson.D{
+			{Key: "_id", Value: oid},
+			{Key: "at", Value: bson.DateTime(1700000000000)},
+			{Key: "bin", Value: bson.Binary{Subtype: 4, Data: make([]byte, 16)}},
+			{Key: "drop", Value: 1.5},
+		}
+		require.Equal(t, marshal(t, doc[:3]), prune(t, doc, "-drop"))
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		_, err := Prune(bson.Raw{0x05, 0x00, 0x00}, &kino.Mask{})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,263 @@
+// Package msgpack applies kino masks to MessagePack encoded values. The input
+// is walked token by token: retained members are copied verbatim and excluded
+// ones are skipped by their length prefixes without being decoded.
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/calumari/kino"
+)
+
+// maxDepth bounds the nesting of arrays and maps.
+const maxDepth = 10000
+
+var errTruncated = errors.New("msgpack: unexpected end of data")
+
+// Project returns the single MessagePack value in data projected by m,
+// resolving keys with kino.Mask.Resolve as kino.MarshalWithMask does. Maps
+// are projected by their str keys; other keys cannot be named by a mask and
+// only survive in Negative mode. Array elements inherit the mask of their
+// parent. Retained maps are re-encoded with the shortest map header for
+// their new size; everything else, including extension values, is copied
+// byte for byte. A nil mask returns the value unchanged.
+func Project(data []byte, m *kino.Mask) ([]byte, error) {
+	p := projector{data: data}
+	out, err := p.project(nil, m, 0)
+	if err != nil {
+		return nil, err
+	}
+	if p.off != len(data) {
+		return nil, fmt.Errorf("msgpack: %d bytes of trailing data", len(data)-p.off)
+	}
+	return out, nil
+}
+
+// kind classifies a value by its header.
+type kind int
+
+const (
+	kindScalar kind = iota
+	kindStr
+	kindArray
+	kindMap
+)
+
+// projector walks data from off.
+type projector struct {
+	data []byte
+	off  int
+}
+
+// uint reads an n-byte big-endian length at p.off.
+func (p *projector) uint(n int) (uint64, error) {
+	if len(p.data)-p.off < n {
+		return 0, errTruncated
+	}
+	buf := p.data[p.off : p.off+n]
+	p.off += n
+	switch n {
+	case 1:
+		return uint64(buf[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf)), nil
+	}
+	return uint64(binary.BigEndian.Uint32(buf)), nil
+}
+
+// header reads the format byte and length fields of the value at p.off. For
+// strings, binaries and extensions n is the payload size that follows (the
+// extension type byte included); for arrays and maps it is the number of
+// elements and pairs.
+func (p *projector) header() (k kind, n uint64, err error) {
+	if p.off >= len(p.data) {
+		return 0, 0, errTruncated
+	}
+	b := p.data[p.off]
+	p.off++
+	switch {
+	case b <= 0x7f, b >= 0xe0, b == 0xc0, b == 0xc2, b == 0xc3:
+		return kindScalar, 0, nil
+	case b <= 0x8f:
+		return kindMap, uint64(b & 0x0f), nil
+	case b <= 0x9f:
+		return kindArray, uint64(b & 0x0f), nil
+	case b <= 0xbf:
+		return kindStr, uint64(b & 0x1f), nil
+	}
+	switch b {
+	case 0xc4, 0xc5, 0xc6: // bin 8/16/32
+		n, err = p.uint(1 << (b - 0xc4))
+		return kindScalar, n, err
+	case 0xc7, 0xc8, 0xc9: // ext 8/16/32
+		n, err = p.uint(1 << (b - 0xc7))
+		return kindScalar, n + 1, err
+	case 0xca: // float 32
+		return kindScalar, 4, nil
+	case 0xcb: // float 64
+		return kindScalar, 8, nil
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8/16/32/64
+		return kindScalar, 1 << (b - 0xcc), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8/16/32/64
+		return kindScalar, 1 << (b - 0xd0), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1/2/4/8/16
+		return kindScalar, 1 + 1<<(b-0xd4), nil
+	case 0xd9, 0xda, 0xdb: // str 8/16/32
+		n, err = p.uint(1 << (b - 0xd9))
+		return kindStr, n, err
+	case 0xdc, 0xdd: // array 16/32
+		n, err = p.uint(2 << (b - 0xdc))
+		return kindArray, n, err
+	case 0xde, 0xdf: // map 16/32
+		n, err = p.uint(2 << (b - 0xde))
+		return kindMap, n, err
+	}
+	return 0, 0, fmt.Errorf("msgpack: invalid format byte 0x%02x at offset %d", b, p.off-1)
+}
+
+// payload advances past the n bytes following a header.
+func (p *projector) payload(n uint64) error {
+	if uint64(len(p.data)-p.off) < n {
+		return errTruncated
+	}
+	p.off += int(n)
+	return nil
+}
+
+// skip advances past one value without decoding it.
+func (p *projector) skip(depth int) error {
+	if depth > maxDepth {
+		return errors.New("msgpack: maximum nesting depth exceeded")
+	}
+	k, n, err := p.header()
+	if err != nil {
+		return err
+	}
+	switch k {
+	case kindArray, kindMap:
+		if k == kindMap {
+			n *= 2
+		}
+		for ; n > 0; n-- {
+			if err := p.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return p.payload(n)
+}
+
+// key reads the map key at p.off. ok is false for keys that are not strs;
+// they are skipped.
+func (p *projector) key() (name string, ok bool, err error) {
+	start := p.off
+	k, n, err := p.header()
+	if err != nil {
+		return "", false, err
+	}
+	if k != kindStr {
+		p.off = start
+		return "", false, p.skip(1)
+	}
+	if err := p.payload(n); err != nil {
+		return "", false, err
+	}
+	name = string(p.data[p.off-int(n) : p.off])
+	if !utf8.ValidString(name) {
+		return "", false, fmt.Errorf("msgpack: invalid UTF-8 in map key at offset %d", start)
+	}
+	return name, true, nil
+}
+
+// member is a retained map entry found while scanning a map.
+type member struct {
+	key   []byte // encoded key
+	start int    // offset of the value
+	sub   *kino.Mask
+}
+
+// project appends the value at p.off projected by m to dst.
+func (p *projector) project(dst []byte, m *kino.Mask, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpack: maximum nesting depth exceeded")
+	}
+	start := p.off
+	if m == nil {
+		if err := p.skip(depth); err != nil {
+			return nil, err
+		}
+		return append(dst, p.data[start:p.off]...), nil
+	}
+	k, n, err := p.header()
+	if err != nil {
+		return nil, err
+	}
+	switch k {
+	case kindArray:
+		dst = append(dst, p.data[start:p.off]...)
+		for ; n > 0; n-- {
+			if dst, err = p.project(dst, m, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	case kindMap:
+		return p.projectMap(dst, m, n, depth)
+	}
+	if err := p.payload(n); err != nil {
+		return nil, err
+	}
+	return append(dst, p.data[start:p.off]...), nil
+}
+
+// projectMap appends the map whose header was just read. A first pass finds
+// the retained members, so that the output header can carry their count; a
+// second pass projects their values.
+func (p *projector) projectMap(dst []byte, m *kino.Mask, n uint64, depth int) ([]byte, error) {
+	var kept []member
+	for ; n > 0; n-- {
+		keyStart := p.off
+		name, ok, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		keep, sub := m.Mode == kino.Negative, (*kino.Mask)(nil)
+		if ok {
+			keep, sub = m.Resolve(name)
+		}
+		if keep {
+			kept = append(kept, member{key: p.data[keyStart:p.off], start: p.off, sub: sub})
+		}
+		if err := p.skip(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	end := p.off
+
+	dst = appendMapHeader(dst, len(kept))
+	for _, mem := range kept {
+		dst = append(dst, mem.key...)
+		p.off = mem.start
+		var err error
+		if dst, err = p.project(dst, mem.sub, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	p.off = end
+	return dst, nil
+}
+
+// appendMapHeader appends the shortest map header for n pairs.
+func appendMapHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x80|byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(dst, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(dst, 0xdf), uint32(n))
+}
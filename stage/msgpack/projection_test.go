@@ -0,0 +1,187 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/calumari/kino"
+	"github.com/stretchr/testify/require"
+)
+
+// obj is an ordered MessagePack map used to build test documents.
+type obj []kv
+
+type kv struct {
+	k any
+	v any
+}
+
+// enc encodes v with the shortest formats.
+func enc(v any) []byte {
+	return appendValue(nil, v)
+}
+
+func appendValue(dst []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, 0xc0)
+	case bool:
+		if v {
+			return append(dst, 0xc3)
+		}
+		return append(dst, 0xc2)
+	case int:
+		switch {
+		case v >= 0 && v <= 0x7f, v < 0 && v >= -32:
+			return append(dst, byte(v))
+		case v >= 0 && v <= 0xffff:
+			return binary.BigEndian.AppendUint16(append(dst, 0xcd), uint16(v))
+		}
+		return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(v))
+	case float64:
+		return binary.BigEndian.AppendUint64(append(dst, 0xcb), math.Float64bits(v))
+	case string:
+		if len(v) < 32 {
+			return append(append(dst, 0xa0|byte(len(v))), v...)
+		}
+		return append(append(dst, 0xd9, byte(len(v))), v...)
+	case []byte:
+		return append(append(dst, 0xc4, byte(len(v))), v...)
+	case []any:
+		dst = append(dst, 0x90|byte(len(v)))
+		for _, e := range v {
+			dst = appendValue(dst, e)
+		}
+		return dst
+	case obj:
+		dst = appendMapHeader(dst, len(v))
+		for _, e := range v {
+			dst = appendValue(dst, e.k)
+			dst = appendValue(dst, e.v)
+		}
+		return dst
+	}
+	panic("unsupported test value")
+}
+
+func sampleDoc() obj {
+	return obj{
+		{"a", "va"},
+		{"b", []byte("vb")},
+		{"c", obj{{"d", 1}, {"e", -2}}},
+		{"z", obj{{"x", 10}, {"y", 1000}}},
+	}
+}
+
+func TestProject(t *testing.T) {
+	project := func(t *testing.T, doc any, mask string) []byte {
+		t.Helper()
+		m, err := kino.ParseMask(mask)
+		require.NoError(t, err)
+		out, err := Project(enc(doc), m)
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("nil mask returns value unchanged", func(t *testing.T) {
+		data := enc(sampleDoc())
+		out, err := Project(data, nil)
+		require.NoError(t, err)
+		require.Equal(t, data, out)
+	})
+
+	t.Run("a,c:(d) positive", func(t *testing.T) {
+		want := obj{{"a", "va"}, {"c", obj{{"d", 1}}}}
+		require.Equal(t, enc(want), project(t, sampleDoc(), "a,c:(d)"))
+	})
+
+	t.Run("-b,-c:(-e) negative", func(t *testing.T) {
+		want := obj{{"a", "va"}, {"c", obj{}}, {"z", obj{{"x", 10}, {"y", 1000}}}}
+		require.Equal(t, enc(want), project(t, sampleDoc(), "-b,-c:(-e)"))
+	})
+
+	t.Run("a,-b,c:(d,-e),-z:(x) mixed", func(t *testing.T) {
+		want := obj{{"a", "va"}, {"c", obj{{"d", 1}}}, {"z", obj{{"x", 10}}}}
+		require.Equal(t, enc(want), project(t, sampleDoc(), "a,-b,c:(d,-e),-z:(x)"))
+	})
+
+	t.Run("arrays inherit mask", func(t *testing.T) {
+		doc := []any{sampleDoc(), "s", sampleDoc()}
+		want := []any{obj{{"z", obj{{"x", 10}}}}, "s", obj{{"z", obj{{"x", 10}}}}}
+		require.Equal(t, enc(want), project(t, doc, "z:(x)"))
+	})
+
+	t.Run("wildcard applies to unlisted keys", func(t *testing.T) {
+		doc := obj{{"items", obj{{"k1", obj{{"id", 1}, {"v", 2}}}, {"k2", obj{{"id", 3}, {"v", 4}}}}}}
+		want := obj{{"items", obj{{"k1", obj{{"id", 1}}}, {"k2", obj{{"id", 3}}}}}}
+		require.Equal(t, enc(want), project(t, doc, "items:(*:(id))"))
+	})
+
+	t.Run("non-str keys kept only in negative mode", func(t *testing.T) {
+		doc := obj{{1, "one"}, {"a", "va"}, {"b", "vb"}}
+		require.Equal(t, enc(obj{{"a", "va"}}), project(t, doc, "a"))
+		require.Equal(t, enc(obj{{1, "one"}, {"a", "va"}}), project(t, doc, "-b"))
+	})
+
+	t.Run("wide formats", func(t *testing.T) {
+		long := "a string that does not fit in a fixstr"
+		doc := obj{
+			{"f", 1.5},
+			{"i", -1 << 40},
+			{"s", long},
+			{"x", []any{nil, true, false}},
+		}
+		require.Equal(t, enc(obj{{"i", -1 << 40}, {"s", long}}), project(t, doc, "i,s"))
+	})
+
+	t.Run("extensions are copied verbatim", func(t *testing.T) {
+		ext := []byte{0xd6, 0xff, 0x00, 0x00, 0x00, 0x01} // timestamp 32
+		data := append(append([]byte{0x82, 0xa1, 'a'}, ext...), 0xa1, 'b', 0xc7, 0x02, 0x01, 0xaa, 0xbb)
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		out, err := Project(data, m)
+		require.NoError(t, err)
+		require.Equal(t, append([]byte{0x81, 0xa1, 'a'}, ext...), out)
+	})
+
+	t.Run("excluded values are skipped without decoding", func(t *testing.T) {
+		// Invalid UTF-8 under an excluded key.
+		data := []byte{0x82, 0xa1, 'a', 0xa2, 0xff, 0xfe, 0xa1, 'b', 0x01}
+		m, err := kino.ParseMask("b")
+		require.NoError(t, err)
+		out, err := Project(data, m)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x81, 0xa1, 'b', 0x01}, out)
+	})
+
+	t.Run("map16 input and output headers", func(t *testing.T) {
+		var doc obj
+		for i := range 20 {
+			doc = append(doc, kv{string(rune('A' + i)), i})
+		}
+		out := project(t, doc, "-A")
+		require.Equal(t, enc(doc[1:]), out)
+		require.Equal(t, []byte{0xde, 0x00, 19}, out[:3])
+		require.Equal(t, enc(doc[:2]), project(t, doc, "A,B"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		m, err := kino.ParseMask("a")
+		require.NoError(t, err)
+		for name, data := range map[string][]byte{
+			"truncated":       enc(sampleDoc())[:10],
+			"trailing data":   append(enc(sampleDoc()), 0x00),
+			"never used":      {0xc1},
+			"invalid utf8":    {0x81, 0xa1, 0xff, 0x00},
+			"empty":           {},
+			"truncated array": {0x92, 0x01},
+			"truncated len":   {0xda, 0x00},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Project(data, m)
+				require.Error(t, err)
+			})
+		}
+	})
+}
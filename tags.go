@@ -0,0 +1,191 @@
+package kino
+
+import (
+	"reflect"
+	"strings"
+)
+
+// maxDefaultRecursion caps how many times DefaultMask and DefaultMaskWith
+// expand the same type along a single path. Restricted values of a recursive
+// type nested deeper than that are dropped rather than emitted unrestricted.
+const maxDefaultRecursion = 4
+
+// DefaultMask builds the default view of T from `kino` struct tags:
+//
+//	SSN  string `json:"ssn" kino:"-"`        // never emitted
+//	Name string `json:"name" kino:"default"` // part of the summary view
+//
+// A struct with `default` fields projects to those fields only; a struct
+// without them projects to all of its JSON fields. Fields tagged `kino:"-"`
+// are excluded in either case. Nested structs (also behind pointers, slices,
+// arrays and as map values, through the Wildcard key) contribute their own
+// default views. Recursive types are expanded a bounded number of times along
+// each path; restricted values nested deeper are dropped. The result is nil
+// when T carries no restrictions at all.
+//
+// Use DefaultMaskWith to layer a client mask on top of the default view.
+func DefaultMask[T any]() *Mask {
+	m, _ := defaultMaskOf(reflect.TypeFor[T](), make(map[reflect.Type]int))
+	return m
+}
+
+// DefaultMaskWith layers client on top of the default view of T. Like
+// DefaultMask[T]().Overlay(client), the default view is extended with the
+// client's fields and excluded fields cannot be re-included since the default
+// entries win. In addition every field the client selects is itself layered
+// on top of the default view of its type, so `kino:"-"` fields stay hidden at
+// any depth, including below fields outside the default view and fields
+// selected through the Wildcard key.
+func DefaultMaskWith[T any](client *Mask) *Mask {
+	m, _ := layerDefault(reflect.TypeFor[T](), client, make(map[reflect.Type]int))
+	return m
+}
+
+// defaultMaskOf returns the default view of t, or nil when t carries no
+// restrictions. cut reports that t is restricted but has already been
+// expanded maxDefaultRecursion times along the path, so the value must be
+// dropped.
+func defaultMaskOf(t reflect.Type, visiting map[reflect.Type]int) (m *Mask, cut bool) {
+	t = defaultElem(t)
+	if !hasKinoTags(t, make(map[reflect.Type]bool)) {
+		return nil, false
+	}
+	if t.Kind() == reflect.Map {
+		return wildcardMask(defaultMaskOf(t.Elem(), visiting)), false
+	}
+	if visiting[t] >= maxDefaultRecursion {
+		return nil, true
+	}
+	visiting[t]++
+	defer func() { visiting[t]-- }()
+
+	fields := jsonFields(t)
+	hasDefault := false
+	for _, f := range fields {
+		if kinoTag(f.tag) == "default" {
+			hasDefault = true
+		}
+	}
+	res := &Mask{Mode: Positive, Fields: make(map[string]*Node, len(fields))}
+	for _, f := range fields {
+		tag := kinoTag(f.tag)
+		if tag != "-" && hasDefault && tag != "default" {
+			continue // outside the default view
+		}
+		node := &Node{Op: Negative}
+		if tag != "-" {
+			if children, cut := defaultMaskOf(f.typ, visiting); !cut {
+				node = &Node{Op: Positive, Children: children}
+			}
+		}
+		res.Fields[f.name] = node
+		res.Order = append(res.Order, f.name)
+	}
+	res.Mode = deriveMode(res)
+	return res, false
+}
+
+// layerDefault returns the default view of t overlaid with client, with the
+// value of every field client selects layered in turn on the default view of
+// its type. cut is reported as by defaultMaskOf.
+func layerDefault(t reflect.Type, client *Mask, visiting map[reflect.Type]int) (m *Mask, cut bool) {
+	base, cut := defaultMaskOf(t, visiting)
+	if cut || client == nil {
+		return base, cut
+	}
+	res := base.Overlay(client)
+	t = defaultElem(t)
+	switch t.Kind() {
+	case reflect.Map:
+		if !hasKinoTags(t, make(map[reflect.Type]bool)) {
+			return res, false
+		}
+		for _, k := range orderedKeys(client) {
+			layerNode(res, k, t.Elem(), client.Fields[k], visiting)
+		}
+	case reflect.Struct:
+		if !hasKinoTags(t, make(map[reflect.Type]bool)) {
+			return res, false
+		}
+		visiting[t]++
+		defer func() { visiting[t]-- }()
+		for _, f := range jsonFields(t) {
+			c, ok := client.Fields[f.name]
+			if !ok {
+				// Fields with an entry of their own ignore the Wildcard.
+				if c, ok = client.Fields[Wildcard]; !ok || res.Fields[f.name] != nil {
+					continue
+				}
+				// Give the field an entry so that it can be restricted.
+				res.Fields[f.name] = cloneNode(c)
+				res.Order = append(res.Order, f.name)
+			}
+			layerNode(res, f.name, f.typ, c, visiting)
+		}
+		res.Mode = deriveMode(res)
+	}
+	return res, false
+}
+
+// layerNode restricts the value of the retained entry key of m, of type t, to
+// the default view of t overlaid with the client's subtree c.
+func layerNode(m *Mask, key string, t reflect.Type, c *Node, visiting map[reflect.Type]int) {
+	n := m.Fields[key]
+	if n.Op == Negative && !n.hasChildren() {
+		return
+	}
+	children, cut := layerDefault(t, c.Children, visiting)
+	if cut {
+		*n = Node{Op: Negative}
+		return
+	}
+	n.Children = children
+}
+
+// wildcardMask returns a mask applying elem to every key of a map, or nil when
+// elem is nil. A cut elem drops every key.
+func wildcardMask(elem *Mask, cut bool) *Mask {
+	switch {
+	case cut:
+		return &Mask{Mode: Negative, Fields: map[string]*Node{Wildcard: {Op: Negative}}, Order: []string{Wildcard}}
+	case elem == nil:
+		return nil
+	}
+	return &Mask{Mode: Positive, Fields: map[string]*Node{Wildcard: {Op: Positive, Children: elem}}, Order: []string{Wildcard}}
+}
+
+// defaultElem returns the type whose fields make up values of t: pointers,
+// slices and arrays are followed to their element type.
+func defaultElem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// hasKinoTags reports whether a struct reachable from t, other than through
+// types that marshal themselves, has a field with a `kino` tag.
+func hasKinoTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	t = defaultElem(t)
+	if seen[t] || marshalsItself(t) {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Map:
+		return hasKinoTags(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range jsonFields(t) {
+			if kinoTag(f.tag) != "" || hasKinoTags(f.typ, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kinoTag returns the first option of the `kino` struct tag.
+func kinoTag(tag reflect.StructTag) string {
+	opt, _, _ := strings.Cut(tag.Get("kino"), ",")
+	return opt
+}
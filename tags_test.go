@@ -0,0 +1,164 @@
+package kino_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+)
+
+type tagMeta struct {
+	Plan     string `json:"plan"`
+	Internal string `json:"internal" kino:"-"`
+}
+
+type tagUser struct {
+	ID    int       `json:"id" kino:"default"`
+	Name  string    `json:"name" kino:"default"`
+	Email string    `json:"email"`
+	SSN   string    `json:"ssn" kino:"-"`
+	Meta  tagMeta   `json:"meta" kino:"default"`
+	Tags  []tagMeta `json:"tags"`
+}
+
+type tagAccount struct {
+	Owner  *tagUser `json:"owner"`
+	Secret string   `json:"secret" kino:"-"`
+	Plain  string   `json:"plain"`
+}
+
+type tagCatalog struct {
+	ByName map[string]tagMeta `json:"by_name"`
+}
+
+type tagTree struct {
+	Name     string    `json:"name"`
+	Secret   string    `json:"secret" kino:"-"`
+	Children []tagTree `json:"children"`
+}
+
+type tagCard struct {
+	Number string `json:"number"`
+}
+
+type tagWallet struct {
+	ID   int     `json:"id"`
+	SSN  string  `json:"ssn" kino:"-"`
+	Card tagCard `json:"card" kino:"-"`
+}
+
+type tagPlain struct {
+	A string `json:"a"`
+	B struct {
+		C string `json:"c"`
+	} `json:"b"`
+}
+
+func buildTagUser() tagUser {
+	return tagUser{
+		ID: 1, Name: "Ada", Email: "ada@example.com", SSN: "123",
+		Meta: tagMeta{Plan: "pro", Internal: "x"},
+		Tags: []tagMeta{{Plan: "t", Internal: "y"}},
+	}
+}
+
+func TestDefaultMask(t *testing.T) {
+	t.Run("untagged type nil", func(t *testing.T) {
+		require.Nil(t, kino.DefaultMask[tagPlain]())
+	})
+
+	t.Run("default fields summary view", func(t *testing.T) {
+		m := kino.DefaultMask[tagUser]()
+		require.Equal(t, "id,meta:(-internal,plan),name,-ssn", m.String())
+		out, err := json.Marshal(buildTagUser(), json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":1,"name":"Ada","meta":{"plan":"pro"}}`, string(out))
+	})
+
+	t.Run("no default fields keeps all but excluded", func(t *testing.T) {
+		m := kino.DefaultMask[tagAccount]()
+		require.Equal(t, kino.Positive, m.Mode)
+		out, err := json.Marshal(tagAccount{Owner: &tagUser{ID: 1, SSN: "s"}, Secret: "s", Plain: "p"}, json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"owner":{"id":1,"name":"","meta":{"plan":""}},"plain":"p"}`, string(out))
+	})
+
+	t.Run("map values restricted through wildcard", func(t *testing.T) {
+		m := kino.DefaultMask[tagCatalog]()
+		require.Equal(t, "by_name:(*:(-internal,plan))", m.String())
+		out, err := json.Marshal(tagCatalog{ByName: map[string]tagMeta{"a": {Plan: "p", Internal: "x"}}}, json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"by_name":{"a":{"plan":"p"}}}`, string(out))
+	})
+
+	t.Run("recursive types restricted up to a bounded depth", func(t *testing.T) {
+		tree := tagTree{Name: "0", Secret: "s"}
+		for i := 1; i <= 5; i++ {
+			tree = tagTree{Name: strconv.Itoa(i), Secret: "s", Children: []tagTree{tree}}
+		}
+		out, err := json.Marshal(tree, json.WithMarshalers(kino.MarshalWithMask(kino.DefaultMask[tagTree]())))
+		require.NoError(t, err)
+		require.NotContains(t, string(out), "secret")
+		// Levels below the recursion limit are dropped, not emitted unrestricted.
+		require.JSONEq(t, `{"name":"5","children":[{"name":"4","children":[{"name":"3","children":[{"name":"2"}]}]}]}`, string(out))
+	})
+}
+
+func TestDefaultMaskWith(t *testing.T) {
+	marshal := func(t *testing.T, expr string) string {
+		t.Helper()
+		client, err := kino.ParseMask(expr)
+		require.NoError(t, err)
+		out, err := json.Marshal(buildTagUser(), json.WithMarshalers(kino.MarshalWithMask(kino.DefaultMaskWith[tagUser](client))))
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	t.Run("nil client is default view", func(t *testing.T) {
+		require.Equal(t, kino.DefaultMask[tagUser]().String(), kino.DefaultMaskWith[tagUser](nil).String())
+	})
+
+	t.Run("client extends but cannot re-include", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"name":"Ada","email":"ada@example.com","meta":{"plan":"pro"},"tags":[{"plan":"t"}]}`, marshal(t, "email,ssn,tags"))
+	})
+
+	t.Run("client subtree cannot re-include", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"name":"Ada","meta":{"plan":"pro"},"tags":[{"plan":"t"}]}`, marshal(t, "tags:(plan,internal),meta:(internal)"))
+	})
+
+	t.Run("wildcard client restricted", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"name":"Ada","email":"ada@example.com","meta":{"plan":"pro"},"tags":[{"plan":"t"}]}`, marshal(t, "*"))
+	})
+
+	t.Run("negative override client restricted", func(t *testing.T) {
+		require.JSONEq(t, `{"id":1,"name":"Ada","meta":{"plan":"pro"},"tags":[{"plan":"t"}]}`, marshal(t, "-tags:(plan,internal)"))
+	})
+
+	t.Run("client children cannot lift exclusions", func(t *testing.T) {
+		w := tagWallet{ID: 1, SSN: "123-45", Card: tagCard{Number: "4111"}}
+		for _, expr := range []string{"ssn:(x)", "card:(number)", "id,ssn:(x),card:(number)"} {
+			client, err := kino.ParseMask(expr)
+			require.NoError(t, err)
+			for _, m := range []*kino.Mask{kino.DefaultMaskWith[tagWallet](client), kino.DefaultMask[tagWallet]().Overlay(client)} {
+				out, err := json.Marshal(w, json.WithMarshalers(kino.MarshalWithMask(m)))
+				require.NoError(t, err)
+				require.JSONEq(t, `{"id":1}`, string(out), "%s: %s", expr, m)
+			}
+		}
+	})
+
+	t.Run("recursive client restricted", func(t *testing.T) {
+		client, err := kino.ParseMask("children:(children:(children:(children:(children))))")
+		require.NoError(t, err)
+		tree := tagTree{Name: "0", Secret: "s"}
+		for i := 1; i <= 5; i++ {
+			tree = tagTree{Name: strconv.Itoa(i), Secret: "s", Children: []tagTree{tree}}
+		}
+		out, err := json.Marshal(tree, json.WithMarshalers(kino.MarshalWithMask(kino.DefaultMaskWith[tagTree](client))))
+		require.NoError(t, err)
+		require.NotContains(t, string(out), "secret")
+	})
+}
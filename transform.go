@@ -0,0 +1,42 @@
+package kino
+
+import (
+	"fmt"
+
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+// Transformer rewrites a JSON value emitted by MarshalWithMask. It receives the
+// value after projection (a subtree only contains what the mask keeps) and
+// must return a single valid JSON value.
+type Transformer func(v jsontext.Value) (jsontext.Value, error)
+
+// WithTransform registers fn for the member at the dotted path (e.g.
+// "user.email", array indices omitted, so the transformer runs for every
+// element of an array along the path). Transformers only run for members the
+// mask keeps; redacted or removed members are never passed to them. A later
+// registration for the same path replaces an earlier one.
+func WithTransform(path string, fn Transformer) Option {
+	return func(o *options) {
+		if o.transforms == nil {
+			o.transforms = make(map[string]Transformer)
+		}
+		o.transforms[path] = fn
+	}
+}
+
+// StringTransform adapts fn into a Transformer for JSON strings, such as
+// hashing or truncating them. Values of other kinds pass through unchanged.
+func StringTransform(fn func(string) string) Transformer {
+	return func(v jsontext.Value) (jsontext.Value, error) {
+		if v.Kind() != '"' {
+			return v, nil
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			return nil, fmt.Errorf("read string: %w", err)
+		}
+		return jsontext.AppendQuote(nil, fn(s))
+	}
+}
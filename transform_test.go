@@ -0,0 +1,84 @@
+package kino_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestMarshalWithMask_Transform(t *testing.T) {
+	const doc = `{"user":{"email":"ada@example.com","name":"Ada Lovelace"},"points":[{"lat":51.123456,"lng":1},{"lat":2}],"n":1}`
+	marshal := func(t *testing.T, expr string, opts ...kino.Option) (string, error) {
+		t.Helper()
+		m, err := kino.ParseMask(expr)
+		require.NoError(t, err)
+		out, err := json.Marshal(jsontext.Value(doc), json.WithMarshalers(kino.MarshalWithMask(m, opts...)))
+		return string(out), err
+	}
+
+	t.Run("string transform on kept path", func(t *testing.T) {
+		got, err := marshal(t, "user", kino.WithTransform("user.email", kino.StringTransform(sha256Hex)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"user":{"email":"`+sha256Hex("ada@example.com")+`","name":"Ada Lovelace"}}`, got)
+	})
+
+	t.Run("excluded path not transformed", func(t *testing.T) {
+		called := false
+		got, err := marshal(t, "user:(name)", kino.WithTransform("user.email", func(v jsontext.Value) (jsontext.Value, error) {
+			called = true
+			return v, nil
+		}))
+		require.NoError(t, err)
+		require.False(t, called)
+		require.JSONEq(t, `{"user":{"name":"Ada Lovelace"}}`, got)
+	})
+
+	t.Run("array elements transformed", func(t *testing.T) {
+		got, err := marshal(t, "points:(lat)", kino.WithTransform("points.lat", func(jsontext.Value) (jsontext.Value, error) {
+			return jsontext.Value("0"), nil
+		}))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"points":[{"lat":0},{"lat":0}]}`, got)
+	})
+
+	t.Run("subtree receives projected value", func(t *testing.T) {
+		var seen string
+		got, err := marshal(t, "user:(-email)",
+			kino.WithTransform("user", func(v jsontext.Value) (jsontext.Value, error) {
+				seen = string(v)
+				return jsontext.Value(`"u"`), nil
+			}),
+			kino.WithTransform("user.name", kino.StringTransform(strings.ToUpper)),
+		)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name":"ADA LOVELACE"}`, seen)
+		require.JSONEq(t, `{"user":"u"}`, got)
+	})
+
+	t.Run("non-string untouched by string transform", func(t *testing.T) {
+		got, err := marshal(t, "n", kino.WithTransform("n", kino.StringTransform(strings.ToUpper)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"n":1}`, got)
+	})
+
+	t.Run("transformer error propagated", func(t *testing.T) {
+		boom := errors.New("boom")
+		_, err := marshal(t, "n", kino.WithTransform("n", func(jsontext.Value) (jsontext.Value, error) {
+			return nil, boom
+		}))
+		require.ErrorIs(t, err, boom)
+	})
+}
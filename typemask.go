@@ -0,0 +1,89 @@
+package kino
+
+import (
+	"encoding"
+	"reflect"
+
+	"github.com/calumari/kino/internal/json"
+)
+
+// maxTypeRecursion caps how many times MaskOf expands the same type along a
+// single path. Deeper occurrences of a recursive type become leaves.
+const maxTypeRecursion = 2
+
+var (
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	jsonMarshalerToType = reflect.TypeFor[json.MarshalerTo]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// MaskOf returns the full field tree of t as a Positive mask, so that String
+// prints the complete selectable surface of the type. Struct fields are named
+// as encoding/json/v2 names them (including inlined and embedded fields),
+// pointers are followed, slice and array elements contribute their own tree,
+// and string-keyed maps select their entries through the Wildcard key. Types
+// that marshal themselves, interfaces and scalars are leaves. Recursive types
+// are expanded a bounded number of times along each path. The result is nil
+// when t has no fields to select.
+func MaskOf(t reflect.Type) *Mask {
+	return maskOf(t, make(map[reflect.Type]int))
+}
+
+// MaskFor is the generic form of MaskOf.
+func MaskFor[T any]() *Mask {
+	return MaskOf(reflect.TypeFor[T]())
+}
+
+func maskOf(t reflect.Type, visiting map[reflect.Type]int) *Mask {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if marshalsItself(t) {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil // encoded as a base64 string
+		}
+		return maskOf(t.Elem(), visiting)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil
+		}
+		return &Mask{
+			Mode:   Positive,
+			Fields: map[string]*Node{Wildcard: {Op: Positive, Children: maskOf(t.Elem(), visiting)}},
+			Order:  []string{Wildcard},
+		}
+	case reflect.Struct:
+		if visiting[t] >= maxTypeRecursion {
+			return nil
+		}
+		visiting[t]++
+		defer func() { visiting[t]-- }()
+		fields := jsonFields(t)
+		if len(fields) == 0 {
+			return nil
+		}
+		res := &Mask{Mode: Positive, Fields: make(map[string]*Node, len(fields))}
+		for _, f := range fields {
+			res.Fields[f.name] = &Node{Op: Positive, Children: maskOf(f.typ, visiting)}
+			res.Order = append(res.Order, f.name)
+		}
+		return res
+	}
+	return nil
+}
+
+// marshalsItself reports whether values of t (or *t) implement one of the
+// marshaling interfaces honored by encoding/json/v2, making their JSON shape
+// opaque to MaskOf.
+func marshalsItself(t reflect.Type) bool {
+	for _, it := range []reflect.Type{jsonMarshalerType, jsonMarshalerToType, textMarshalerType} {
+		if t.Implements(it) || reflect.PointerTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
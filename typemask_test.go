@@ -0,0 +1,158 @@
+package kino_test
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/internal/json"
+	"github.com/calumari/kino/internal/jsontext"
+)
+
+type typeMaskTree struct {
+	Value    int             `json:"value"`
+	Children []*typeMaskTree `json:"children"`
+}
+
+type typeMaskBase struct {
+	ID int `json:"id"`
+}
+
+type typeMaskUser struct {
+	typeMaskBase
+	Name    string               `json:"name"`
+	Meta    *applyMeta           `json:"meta"`
+	Tags    []applyMeta          `json:"tags"`
+	Extra   map[string]applyMeta `json:"extra"`
+	Counts  map[string]int       `json:"counts"`
+	Created time.Time            `json:"created"`
+	Raw     []byte               `json:"raw"`
+	Any     any                  `json:"any"`
+	Hidden  string               `json:"-"`
+	NoTag   string
+}
+
+type typeMaskLeft struct {
+	Plain int
+	Named int `json:"Label"`
+}
+
+type typeMaskRight struct {
+	Plain int
+	Label int
+}
+
+type typeMaskCatchAll struct {
+	typeMaskLeft
+	typeMaskRight
+	ID    int            `json:"id"`
+	Extra map[string]any `json:",inline"`
+}
+
+type typeMaskEmbed struct {
+	ID    int            `json:"id"`
+	Extra map[string]any `json:",embed"`
+}
+
+type typeMaskUnknown struct {
+	ID   int            `json:"id"`
+	Rest jsontext.Value `json:",unknown"`
+}
+
+func TestMaskOf(t *testing.T) {
+	t.Run("scalar nil", func(t *testing.T) {
+		require.Nil(t, kino.MaskFor[int]())
+		require.Nil(t, kino.MaskFor[time.Time]())
+	})
+
+	t.Run("full surface", func(t *testing.T) {
+		m := kino.MaskOf(reflect.TypeFor[*typeMaskUser]())
+		require.Equal(t, "NoTag,any,counts:(*),created,extra:(*:(internal,plan)),id,meta:(internal,plan),name,raw,tags:(internal,plan)", m.String())
+		require.Equal(t, []string{"id", "name", "meta", "tags", "extra", "counts", "created", "raw", "any", "NoTag"}, m.Order)
+	})
+
+	t.Run("recursive type capped", func(t *testing.T) {
+		require.Equal(t, "children:(children,value),value", kino.MaskFor[typeMaskTree]().String())
+	})
+
+	t.Run("member names match v2", func(t *testing.T) {
+		for _, v := range []any{
+			typeMaskCatchAll{
+				typeMaskLeft:  typeMaskLeft{Plain: 1, Named: 2},
+				typeMaskRight: typeMaskRight{Plain: 3, Label: 4},
+				ID:            5,
+				Extra:         map[string]any{},
+			},
+			typeMaskUnknown{ID: 1, Rest: jsontext.Value(`{}`)},
+			typeMaskEmbed{ID: 1, Extra: map[string]any{}},
+		} {
+			b, err := json.Marshal(v)
+			require.NoError(t, err)
+			var obj map[string]any
+			require.NoError(t, json.Unmarshal(b, &obj))
+			m := kino.MaskOf(reflect.TypeOf(v))
+			require.ElementsMatch(t, slices.Collect(maps.Keys(obj)), m.Order, "%T: %s", v, b)
+		}
+
+		m, err := kino.ParseMask("Plain")
+		require.NoError(t, err)
+		require.Error(t, m.ValidateFor(reflect.TypeFor[typeMaskCatchAll]()))
+	})
+
+	t.Run("projects everything", func(t *testing.T) {
+		u := typeMaskUser{
+			typeMaskBase: typeMaskBase{ID: 1},
+			Name:         "Ada",
+			Meta:         &applyMeta{Plan: "pro", Internal: "x"},
+			Extra:        map[string]applyMeta{"k": {Plan: "p"}},
+			Counts:       map[string]int{"a": 1},
+			Any:          map[string]any{"x": 1},
+		}
+		want, err := json.Marshal(u)
+		require.NoError(t, err)
+		got, err := json.Marshal(u, json.WithMarshalers(kino.MarshalWithMask(kino.MaskFor[typeMaskUser]())))
+		require.NoError(t, err)
+		require.JSONEq(t, string(want), string(got))
+	})
+}
+
+func TestWildcard(t *testing.T) {
+	in := `{"a":{"plan":"p","internal":"i"},"b":{"plan":"q"},"c":1}`
+
+	t.Run("selects every entry", func(t *testing.T) {
+		m, err := kino.ParseMask("*:(plan)")
+		require.NoError(t, err)
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(in), &v))
+		out, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m)))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":{"plan":"p"},"b":{"plan":"q"},"c":1}`, string(out))
+		got, err := json.Marshal(kino.Prune(v, m))
+		require.NoError(t, err)
+		require.JSONEq(t, string(out), string(got))
+	})
+
+	t.Run("explicit entries win", func(t *testing.T) {
+		m, err := kino.ParseMask("*:(plan),-b")
+		require.NoError(t, err)
+		var v any
+		require.NoError(t, json.Unmarshal([]byte(in), &v))
+		out, err := json.Marshal(v, json.WithMarshalers(kino.MarshalWithMask(m, kino.WithExplicitNulls())))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a":{"plan":"p"},"c":1}`, string(out))
+	})
+
+	t.Run("matched for report", func(t *testing.T) {
+		m, err := kino.ParseMask("*:(plan),x")
+		require.NoError(t, err)
+		var r kino.Report
+		_, err = json.Marshal(map[string]map[string]int{"a": {"plan": 1}}, json.WithMarshalers(kino.MarshalWithMask(m, kino.WithReport(&r))))
+		require.NoError(t, err)
+		require.Equal(t, []string{"x"}, r.Unmatched)
+	})
+}
@@ -0,0 +1,152 @@
+package kino
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions caps the "did you mean" candidates of an UnknownFieldError.
+const maxSuggestions = 3
+
+// UnknownFieldError reports a mask path that does not name a field of the type
+// the mask was validated for.
+type UnknownFieldError struct {
+	// Path is the dotted path of the unknown field.
+	Path string
+	// Suggestions lists the closest existing field names at the same level,
+	// closest first.
+	Suggestions []string
+}
+
+func (e *UnknownFieldError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown field %q", e.Path)
+	}
+	return fmt.Sprintf("unknown field %q (did you mean %s?)", e.Path, quoteJoin(e.Suggestions))
+}
+
+// ValidateFor checks that every path of m names a field of t, resolving names
+// the way encoding/json/v2 does: json tags, inlined and embedded structs,
+// pointers, and the elements of slices and arrays. Any key is accepted for maps
+// and interfaces, as well as below types that marshal themselves. The Wildcard
+// key is accepted everywhere.
+//
+// The result is nil when m is valid, otherwise an errors.Join of one
+// *UnknownFieldError per unknown path in sorted order. Paths below an unknown
+// field are not reported separately.
+func (m *Mask) ValidateFor(t reflect.Type) error {
+	var errs []error
+	validateMask(m, t, nil, &errs)
+	return errors.Join(errs...)
+}
+
+func validateMask(m *Mask, t reflect.Type, path []string, errs *[]error) {
+	if m == nil || len(m.Fields) == 0 {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if marshalsItself(t) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() != reflect.Uint8 {
+			validateMask(m, t.Elem(), path, errs)
+			return
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(m) {
+			validateMask(m.Fields[k].Children, t.Elem(), append(path, k), errs)
+		}
+		return
+	case reflect.Struct:
+		fields := jsonFields(t)
+		byName := make(map[string]reflect.Type, len(fields))
+		for _, f := range fields {
+			byName[f.name] = f.typ
+		}
+		for _, k := range sortedKeys(m) {
+			p := append(path, k)
+			if k == Wildcard {
+				continue
+			}
+			ft, ok := byName[k]
+			if !ok {
+				*errs = append(*errs, &UnknownFieldError{Path: strings.Join(p, "."), Suggestions: suggest(k, fields)})
+				continue
+			}
+			validateMask(m.Fields[k].Children, ft, p, errs)
+		}
+		return
+	}
+	// Scalars have no fields.
+	for _, k := range sortedKeys(m) {
+		if k != Wildcard {
+			*errs = append(*errs, &UnknownFieldError{Path: strings.Join(append(path, k), ".")})
+		}
+	}
+}
+
+// suggest returns the names of fields within edit distance of name, closest
+// first.
+func suggest(name string, fields []jsonField) []string {
+	type candidate struct {
+		name string
+		dist int
+	}
+	limit := max(2, len(name)/3)
+	var cands []candidate
+	for _, f := range fields {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(f.name)); d <= limit {
+			cands = append(cands, candidate{f.name, d})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		if cands[i].dist != cands[j].dist {
+			return cands[i].dist < cands[j].dist
+		}
+		return cands[i].name < cands[j].name
+	})
+	var res []string
+	for i := 0; i < len(cands) && i < maxSuggestions; i++ {
+		res = append(res, cands[i].name)
+	}
+	return res
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// quoteJoin formats names as a quoted, "or"-separated list.
+func quoteJoin(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = fmt.Sprintf("%q", n)
+	}
+	return strings.Join(quoted, " or ")
+}
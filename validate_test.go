@@ -0,0 +1,48 @@
+package kino_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/calumari/kino"
+)
+
+func TestValidateFor(t *testing.T) {
+	typ := reflect.TypeFor[*typeMaskUser]()
+
+	t.Run("nil mask valid", func(t *testing.T) {
+		var m *kino.Mask
+		require.NoError(t, m.ValidateFor(typ))
+	})
+
+	t.Run("known paths valid", func(t *testing.T) {
+		m, err := kino.ParseMask("id,NoTag,meta:(plan),tags:(-internal),extra:(anything:(plan),*),any:(x:(y)),created:(wall)")
+		require.NoError(t, err)
+		require.NoError(t, m.ValidateFor(typ))
+	})
+
+	t.Run("unknown paths with suggestions", func(t *testing.T) {
+		m, err := kino.ParseMask("nmae,meta:(plna),tags:(zzz:(a)),name:(first),extra:(k:(interal))")
+		require.NoError(t, err)
+		err = m.ValidateFor(typ)
+		require.Error(t, err)
+
+		var got []kino.UnknownFieldError
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var ufe *kino.UnknownFieldError
+			require.True(t, errors.As(e, &ufe))
+			got = append(got, *ufe)
+		}
+		require.Equal(t, []kino.UnknownFieldError{
+			{Path: "extra.k.interal", Suggestions: []string{"internal"}},
+			{Path: "meta.plna", Suggestions: []string{"plan"}},
+			{Path: "name.first"},
+			{Path: "nmae", Suggestions: []string{"name"}},
+			{Path: "tags.zzz"},
+		}, got)
+		require.Contains(t, err.Error(), `unknown field "meta.plna" (did you mean "plan"?)`)
+	})
+}
@@ -0,0 +1,133 @@
+// Package yaml applies kino masks to YAML documents built with
+// gopkg.in/yaml.v3, using the same Positive/Negative/override semantics as
+// kino.MarshalWithMask. Retained nodes keep their comments, styles and key
+// order.
+package yaml
+
+import (
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/calumari/kino"
+)
+
+// Apply projects the node tree rooted at n in place: mapping entries the mask
+// drops are removed, retained values are projected by their sub-masks, and
+// sequence elements inherit the mask of their parent. Document nodes apply
+// the mask to their content and scalars are left untouched. Mapping keys that
+// are not scalars cannot be named by a mask, so they only survive in Negative
+// mode.
+//
+// Anchored nodes that must be narrowed are replaced by a projected copy, and
+// so are aliases whose target must be narrowed, so that other references to
+// the anchor see the whole node. Aliases whose anchor no longer precedes them
+// in the output are replaced by a copy of their target. Merge keys ("<<") are
+// matched like any other key. A nil mask leaves the tree unchanged.
+func Apply(n *yamlv3.Node, m *kino.Mask) {
+	if n == nil || m == nil {
+		return
+	}
+	apply(n, m)
+	inlineAliases(n, make(map[*yamlv3.Node]bool), make(map[*yamlv3.Node]bool))
+}
+
+// apply projects n in place, as described by Apply, leaving aliases to
+// anchors that were dropped or narrowed dangling.
+func apply(n *yamlv3.Node, m *kino.Mask) {
+	switch n.Kind {
+	case yamlv3.DocumentNode:
+		for _, c := range n.Content {
+			apply(c, m)
+		}
+	case yamlv3.SequenceNode:
+		for i, c := range n.Content {
+			n.Content[i] = project(c, m)
+		}
+	case yamlv3.MappingNode:
+		kept := n.Content[:0]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			keep, sub := resolve(m, key)
+			if !keep {
+				continue
+			}
+			kept = append(kept, key, project(val, sub))
+		}
+		clear(n.Content[len(kept):])
+		n.Content = kept
+	case yamlv3.AliasNode:
+		if n.Alias != nil {
+			*n = *project(n, m)
+		}
+	}
+}
+
+// Marshal encodes v to YAML like yaml.Marshal, projected by m.
+func Marshal(v any, m *kino.Mask) ([]byte, error) {
+	var n yamlv3.Node
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	Apply(&n, m)
+	return yamlv3.Marshal(&n)
+}
+
+// resolve applies the mask rules to the mapping key node. Keys that are not
+// scalars cannot be named by a mask and only survive in Negative mode.
+func resolve(m *kino.Mask, key *yamlv3.Node) (keep bool, sub *kino.Mask) {
+	if key.Kind != yamlv3.ScalarNode {
+		return m.Mode == kino.Negative, nil
+	}
+	return m.Resolve(key.Value)
+}
+
+// project returns n projected by m. Anchored nodes and the targets of aliases
+// are projected as a copy, leaving the original for other aliases.
+func project(n *yamlv3.Node, m *kino.Mask) *yamlv3.Node {
+	if m == nil {
+		return n
+	}
+	if n.Kind == yamlv3.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Anchor != "" {
+		n = deepCopy(n)
+	}
+	apply(n, m)
+	return n
+}
+
+// inlineAliases walks n in document order and replaces every alias whose
+// target is not defined before it with a copy of the target. defined holds
+// the anchored nodes seen so far, expanding the targets being inlined, whose
+// recursive aliases are left alone.
+func inlineAliases(n *yamlv3.Node, defined, expanding map[*yamlv3.Node]bool) {
+	if n.Anchor != "" {
+		defined[n] = true
+	}
+	for i, c := range n.Content {
+		if c.Kind == yamlv3.AliasNode && c.Alias != nil && !defined[c.Alias] && !expanding[c.Alias] {
+			target := c.Alias
+			c = deepCopy(target)
+			n.Content[i] = c
+			expanding[target] = true
+			inlineAliases(c, defined, expanding)
+			delete(expanding, target)
+			continue
+		}
+		inlineAliases(c, defined, expanding)
+	}
+}
+
+// deepCopy returns a copy of the tree rooted at n without anchors. Aliases
+// inside it keep pointing at their original targets.
+func deepCopy(n *yamlv3.Node) *yamlv3.Node {
+	cp := *n
+	cp.Anchor = ""
+	if n.Content != nil {
+		cp.Content = make([]*yamlv3.Node, len(n.Content))
+		for i, c := range n.Content {
+			cp.Content[i] = deepCopy(c)
+		}
+	}
+	return &cp
+}
@@ -0,0 +1,133 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/calumari/kino"
+	"github.com/calumari/kino/yaml"
+)
+
+func applyString(t *testing.T, doc, expr string) string {
+	t.Helper()
+	m, err := kino.ParseMask(expr)
+	require.NoError(t, err)
+	var n yamlv3.Node
+	require.NoError(t, yamlv3.Unmarshal([]byte(doc), &n))
+	yaml.Apply(&n, m)
+	out, err := yamlv3.Marshal(&n)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestApply(t *testing.T) {
+	t.Run("nil mask untouched", func(t *testing.T) {
+		var n yamlv3.Node
+		require.NoError(t, yamlv3.Unmarshal([]byte("a: 1\n"), &n))
+		yaml.Apply(&n, nil)
+		out, err := yamlv3.Marshal(&n)
+		require.NoError(t, err)
+		require.Equal(t, "a: 1\n", string(out))
+	})
+
+	t.Run("comments and key order preserved", func(t *testing.T) {
+		doc := `# service config
+name: api # the name
+secret: hunter2
+# limits
+limits:
+  cpu: 2
+  memory: 1Gi
+tags: [a, b]
+`
+		want := `# service config
+name: api # the name
+# limits
+limits:
+    memory: 1Gi
+tags: [a, b]
+`
+		require.Equal(t, want, applyString(t, doc, "name,limits:(memory),tags"))
+		require.Equal(t, want, applyString(t, doc, "-secret,-limits:(memory)"))
+	})
+
+	t.Run("-z:(x) override and sequences", func(t *testing.T) {
+		doc := "z:\n  x: 1\n  y: 2\nitems:\n  - id: 1\n    z: {x: 1, y: 2}\n  - id: 2\n"
+		require.Equal(t, "z:\n    x: 1\nitems:\n    - id: 1\n      z: {x: 1}\n    - id: 2\n", applyString(t, doc, "-z:(x),items:(-z:(x))"))
+	})
+
+	t.Run("aliases narrowed by copy", func(t *testing.T) {
+		doc := "base: &b\n  x: 1\n  y: 2\nfull: *b\nnarrow: *b\n"
+		require.Equal(t, "base: &b\n    x: 1\n    y: 2\nfull: *b\nnarrow:\n    x: 1\n", applyString(t, doc, "base,full,narrow:(x)"))
+	})
+
+	t.Run("aliases of dropped anchors inlined", func(t *testing.T) {
+		doc := "base: &b\n  x: 1\n  y: 2\nother: *b\n"
+		for _, expr := range []string{"other", "-base"} {
+			out := applyString(t, doc, expr)
+			require.Equal(t, "other:\n    x: 1\n    y: 2\n", out, expr)
+			var v map[string]any
+			require.NoError(t, yamlv3.Unmarshal([]byte(out), &v), expr)
+		}
+	})
+
+	t.Run("narrowed anchors leave aliases whole", func(t *testing.T) {
+		doc := "base: &b\n  x: 1\n  y: 2\nother: *b\nlist: [*b]\n"
+		require.Equal(t, "base:\n    x: 1\nother:\n    x: 1\n    y: 2\nlist: [{x: 1, y: 2}]\n", applyString(t, doc, "base:(x),other,list"))
+	})
+
+	t.Run("merge keys of dropped anchors inlined", func(t *testing.T) {
+		doc := "base: &b {x: 1}\nsvc:\n  <<: *b\n  y: 2\n"
+		out := applyString(t, doc, "svc")
+		require.NotContains(t, out, "*b")
+		var v map[string]map[string]int
+		require.NoError(t, yamlv3.Unmarshal([]byte(out), &v))
+		require.Equal(t, map[string]map[string]int{"svc": {"x": 1, "y": 2}}, v)
+	})
+}
+
+// TestApply_MatchesPrune checks that Apply agrees with kino.Prune on JSON-like
+// documents.
+func TestApply_MatchesPrune(t *testing.T) {
+	docs := []string{
+		`{"a":"va","b":"vb","c":{"d":1,"e":2},"z":{"x":10,"y":20}}`,
+		`[{"a":1,"z":{"x":1,"y":2}},{"b":2,"z":[{"x":3},{"y":4}]},5]`,
+		`{"meta":{"plan":"pro","internal":{"secret":true}},"items":[{"id":1,"tags":["x"]}]}`,
+	}
+	exprs := []string{"a", "-b", "a,-b,c:(d,-e),-z:(x)", "-b,-c:(-e)", "z:(-x)", "meta:(plan),items:(id)", "-meta:(internal:(secret))", "*:(x)"}
+	for _, doc := range docs {
+		for _, expr := range exprs {
+			t.Run(expr+" "+doc, func(t *testing.T) {
+				m, err := kino.ParseMask(expr)
+				require.NoError(t, err)
+				var v any
+				require.NoError(t, yamlv3.Unmarshal([]byte(doc), &v))
+				var n yamlv3.Node
+				require.NoError(t, yamlv3.Unmarshal([]byte(doc), &n))
+				yaml.Apply(&n, m)
+				var got any
+				require.NoError(t, n.Decode(&got))
+				require.Equal(t, kino.Prune(v, m), got)
+			})
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type meta struct {
+		Plan     string `yaml:"plan"`
+		Internal string `yaml:"internal"`
+	}
+	type user struct {
+		ID   int    `yaml:"id"`
+		Name string `yaml:"name"`
+		Meta meta   `yaml:"meta"`
+	}
+	m, err := kino.ParseMask("id,meta:(plan)")
+	require.NoError(t, err)
+	out, err := yaml.Marshal(user{ID: 1, Name: "Ada", Meta: meta{Plan: "pro", Internal: "x"}}, m)
+	require.NoError(t, err)
+	require.Equal(t, "id: 1\nmeta:\n    plan: pro\n", string(out))
+}